@@ -1,6 +1,7 @@
 package ucodesdk
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -54,6 +55,11 @@ func (a *Register) Headers(headers map[string]string) *Register {
 	return a
 }
 
+func (a *Register) Context(ctx context.Context) *Register {
+	a.ctx = ctx
+	return a
+}
+
 func (a *Register) Exec() (RegisterResponse, Response, error) {
 	var (
 		response = Response{
@@ -63,7 +69,7 @@ func (a *Register) Exec() (RegisterResponse, Response, error) {
 		url            = fmt.Sprintf("%s/v2/register?project-id=%s", a.config.BaseAuthUrl, a.config.ProjectId)
 	)
 
-	registerResponseInByte, err := DoRequest(url, http.MethodPost, a.data.Body, a.data.Headers)
+	registerResponseInByte, err := doRequest(orBackground(a.ctx), a.config, requestInfo{operation: "auth.register"}, url, http.MethodPost, a.data.Body, a.data.Headers)
 	if err != nil {
 		response.Data = map[string]any{"description": string(registerResponseInByte), "message": "Can't send request", "error": err.Error()}
 		response.Status = "error"
@@ -92,6 +98,11 @@ func (a *ResetPassword) Headers(headers map[string]string) *ResetPassword {
 	return a
 }
 
+func (a *ResetPassword) Context(ctx context.Context) *ResetPassword {
+	a.ctx = ctx
+	return a
+}
+
 func (a *ResetPassword) Exec() (Response, error) {
 	var (
 		response = Response{Status: "done"}
@@ -105,7 +116,7 @@ func (a *ResetPassword) Exec() (Response, error) {
 		"X-API-KEY":     appId,
 	}
 
-	_, err := DoRequest(url, http.MethodPut, a.data.Body, header)
+	_, err := doRequest(orBackground(a.ctx), a.config, requestInfo{operation: "auth.reset_password"}, url, http.MethodPut, a.data.Body, header)
 	if err != nil {
 		response.Data = map[string]any{"message": "Error while reset password", "error": err.Error()}
 		response.Status = "error"
@@ -127,6 +138,11 @@ func (a *Login) Headers(headers map[string]string) *Login {
 	return a
 }
 
+func (a *Login) Context(ctx context.Context) *Login {
+	a.ctx = ctx
+	return a
+}
+
 func (a *Login) Exec() (LoginResponse, Response, error) {
 	var (
 		response    = Response{Status: "done"}
@@ -138,7 +154,7 @@ func (a *Login) Exec() (LoginResponse, Response, error) {
 		a.data.Body["project_id"] = a.config.ProjectId
 	}
 
-	loginResponseInByte, err := DoRequest(url, http.MethodPost, a.data.Body, a.data.Headers)
+	loginResponseInByte, err := doRequest(orBackground(a.ctx), a.config, requestInfo{operation: "auth.login"}, url, http.MethodPost, a.data.Body, a.data.Headers)
 	if err != nil {
 		response.Data = map[string]any{"description": string(loginResponseInByte), "message": "Can't send request", "error": err.Error()}
 		response.Status = "error"
@@ -162,7 +178,7 @@ func (a *Login) ExecWithOption() (LoginWithOptionResponse, Response, error) {
 		url         = fmt.Sprintf("%s/v2/login/with-option?project-id=%s", a.config.BaseAuthUrl, a.config.ProjectId)
 	)
 
-	loginResponseInByte, err := DoRequest(url, http.MethodPost, a.data.Body, a.data.Headers)
+	loginResponseInByte, err := doRequest(orBackground(a.ctx), a.config, requestInfo{operation: "auth.login_with_option"}, url, http.MethodPost, a.data.Body, a.data.Headers)
 	if err != nil {
 		response.Data = map[string]any{"description": string(loginResponseInByte), "message": "Can't send request", "error": err.Error()}
 		response.Status = "error"
@@ -191,6 +207,11 @@ func (a *SendCode) Headers(headers map[string]string) *SendCode {
 	return a
 }
 
+func (a *SendCode) Context(ctx context.Context) *SendCode {
+	a.ctx = ctx
+	return a
+}
+
 func (a *SendCode) Exec() (SendCodeResponse, Response, error) {
 	var (
 		response   = Response{Status: "done"}
@@ -198,7 +219,7 @@ func (a *SendCode) Exec() (SendCodeResponse, Response, error) {
 		url        = fmt.Sprintf("%s/v2/send-code", a.config.BaseAuthUrl)
 	)
 
-	codeResponseInByte, err := DoRequest(url, http.MethodPost, a.data.Body, a.data.Headers)
+	codeResponseInByte, err := doRequest(orBackground(a.ctx), a.config, requestInfo{operation: "auth.send_code"}, url, http.MethodPost, a.data.Body, a.data.Headers)
 	if err != nil {
 		response.Data = map[string]any{"description": string(codeResponseInByte), "message": "Can't send request", "error": err.Error()}
 		response.Status = "error"
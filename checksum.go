@@ -0,0 +1,195 @@
+package ucodesdk
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+	"sync"
+)
+
+// ErrChecksumMismatch is returned when the size or hash reported by the server
+// for an uploaded file differs from the content that was sent.
+var ErrChecksumMismatch = errors.New("uploaded file checksum mismatch")
+
+type FileChecksums struct {
+	SHA256 string `json:"sha256"`
+	MD5    string `json:"md5"`
+	Size   int64  `json:"size"`
+}
+
+// checksumWriter computes SHA-256, MD5 and size of everything written to it.
+type checksumWriter struct {
+	sha  hash.Hash
+	md5  hash.Hash
+	size int64
+}
+
+func newChecksumWriter() *checksumWriter {
+	return &checksumWriter{sha: sha256.New(), md5: md5.New()}
+}
+
+func (w *checksumWriter) Write(p []byte) (int, error) {
+	w.sha.Write(p)
+	w.md5.Write(p)
+	w.size += int64(len(p))
+	return len(p), nil
+}
+
+func (w *checksumWriter) Sum() FileChecksums {
+	return FileChecksums{
+		SHA256: hex.EncodeToString(w.sha.Sum(nil)),
+		MD5:    hex.EncodeToString(w.md5.Sum(nil)),
+		Size:   w.size,
+	}
+}
+
+func fileChecksums(filePath string) (FileChecksums, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return FileChecksums{}, err
+	}
+	defer file.Close()
+
+	writer := newChecksumWriter()
+	if _, err := io.Copy(writer, file); err != nil {
+		return FileChecksums{}, err
+	}
+
+	return writer.Sum(), nil
+}
+
+// verifyUploadedFile compares local checksums with the values the server
+// reported. Values the server did not report are not compared.
+func verifyUploadedFile(local FileChecksums, remote CreateFileResponse) error {
+	if remote.Data.FileSize > 0 && int64(remote.Data.FileSize) != local.Size {
+		return fmt.Errorf("%w: size %d, server reported %d", ErrChecksumMismatch, local.Size, remote.Data.FileSize)
+	}
+
+	if remote.Data.SHA256 != "" && !strings.EqualFold(remote.Data.SHA256, local.SHA256) {
+		return fmt.Errorf("%w: sha256 %s, server reported %s", ErrChecksumMismatch, local.SHA256, remote.Data.SHA256)
+	}
+
+	if remote.Data.MD5 != "" && !strings.EqualFold(remote.Data.MD5, local.MD5) {
+		return fmt.Errorf("%w: md5 %s, server reported %s", ErrChecksumMismatch, local.MD5, remote.Data.MD5)
+	}
+
+	return nil
+}
+
+// FileDedupCache maps uploaded content to the file the server created for it,
+// keys are built by FileDedupKey. Entries are not removed when a file is deleted
+// through Files().Delete, call Remove to forget them.
+type FileDedupCache interface {
+	Get(key string) (CreateFileResponse, bool)
+	Set(key string, file CreateFileResponse) error
+	Remove(key string) error
+}
+
+// FileDedupKey returns the dedup cache key of content with the SHA-256 checksum
+// uploaded into folderName. The same content in another folder is a different file.
+func FileDedupKey(folderName, checksum string) string {
+	return folderName + ":" + checksum
+}
+
+type memoryDedupCache struct {
+	mu    sync.RWMutex
+	files map[string]CreateFileResponse
+}
+
+// NewMemoryDedupCache returns a FileDedupCache that lives as long as the process.
+func NewMemoryDedupCache() FileDedupCache {
+	return &memoryDedupCache{files: map[string]CreateFileResponse{}}
+}
+
+func (c *memoryDedupCache) Get(key string) (CreateFileResponse, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	file, ok := c.files[key]
+	return file, ok
+}
+
+func (c *memoryDedupCache) Set(key string, file CreateFileResponse) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.files[key] = file
+	return nil
+}
+
+func (c *memoryDedupCache) Remove(key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.files, key)
+	return nil
+}
+
+type fileDedupCache struct {
+	memoryDedupCache
+	path string
+}
+
+// NewFileDedupCache returns a FileDedupCache persisted as JSON at path,
+// so identical content is not uploaded again across runs.
+func NewFileDedupCache(path string) (FileDedupCache, error) {
+	cache := &fileDedupCache{
+		memoryDedupCache: memoryDedupCache{files: map[string]CreateFileResponse{}},
+		path:             path,
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cache, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &cache.files); err != nil {
+		return nil, err
+	}
+
+	if cache.files == nil {
+		cache.files = map[string]CreateFileResponse{}
+	}
+
+	return cache, nil
+}
+
+func (c *fileDedupCache) Set(key string, file CreateFileResponse) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.files[key] = file
+	return c.save()
+}
+
+func (c *fileDedupCache) Remove(key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.files, key)
+	return c.save()
+}
+
+func (c *fileDedupCache) save() error {
+	data, err := json.MarshalIndent(c.files, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, c.path)
+}
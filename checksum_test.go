@@ -0,0 +1,113 @@
+package ucodesdk
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUploadChecksumAndDedup(t *testing.T) {
+	var (
+		uploads  int32
+		fileSize = 5
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&uploads, 1)
+
+		var resp CreateFileResponse
+		resp.Data.ID = "file-id"
+		resp.Data.FileSize = fileSize
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "hello.txt")
+	assert.NoError(t, os.WriteFile(path, []byte("hello"), 0o644))
+
+	var (
+		api   = New(&Config{BaseURL: server.URL})
+		cache = NewMemoryDedupCache()
+	)
+
+	created, _, err := api.Files().Upload(path).Dedup(cache).Exec()
+	assert.NoError(t, err)
+	assert.Equal(t, "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824", created.Checksums.SHA256)
+	assert.Equal(t, "5d41402abc4b2a76b9719d911017c592", created.Checksums.MD5)
+
+	created, response, err := api.Files().Upload(path).Dedup(cache).Exec()
+	assert.NoError(t, err)
+	assert.Equal(t, "file-id", created.Data.ID)
+	assert.Equal(t, true, response.Data["deduplicated"])
+	assert.Equal(t, int32(1), atomic.LoadInt32(&uploads))
+
+	fileSize = 4
+	_, _, err = api.Files().Upload(path).Exec()
+	assert.True(t, errors.Is(err, ErrChecksumMismatch))
+}
+
+func TestUploadDedupFailedAndOtherFolder(t *testing.T) {
+	var (
+		uploads int32
+		status  atomic.Int32
+	)
+	status.Store(http.StatusOK)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&uploads, 1)
+
+		var resp CreateFileResponse
+		switch status.Load() {
+		case http.StatusOK:
+			resp.Data.ID = fmt.Sprintf("file-%d", n)
+		case http.StatusAccepted:
+			// a success status without a file, nothing to cache
+		default:
+			w.WriteHeader(int(status.Load()))
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "hello.txt")
+	assert.NoError(t, os.WriteFile(path, []byte("hello"), 0o644))
+
+	var (
+		api   = New(&Config{BaseURL: server.URL})
+		cache = NewMemoryDedupCache()
+	)
+
+	status.Store(http.StatusInternalServerError)
+	_, _, err := api.Files().Upload(path).Dedup(cache).Exec()
+	var statusErr *StatusError
+	assert.True(t, errors.As(err, &statusErr))
+	assert.Equal(t, http.StatusInternalServerError, statusErr.StatusCode)
+
+	status.Store(http.StatusAccepted)
+	_, _, err = api.Files().Upload(path).Dedup(cache).Exec()
+	assert.NoError(t, err)
+
+	status.Store(http.StatusOK)
+	created, response, err := api.Files().Upload(path).Dedup(cache).Exec()
+	assert.NoError(t, err)
+	assert.Equal(t, "file-3", created.Data.ID)
+	assert.Nil(t, response.Data["deduplicated"])
+
+	created, _, err = api.Files().Upload(path).FolderName("Other").Dedup(cache).Exec()
+	assert.NoError(t, err)
+	assert.Equal(t, "file-4", created.Data.ID)
+
+	created, response, err = api.Files().Upload(path).Dedup(cache).Exec()
+	assert.NoError(t, err)
+	assert.Equal(t, "file-3", created.Data.ID)
+	assert.Equal(t, true, response.Data["deduplicated"])
+	assert.Equal(t, int32(4), atomic.LoadInt32(&uploads))
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/ucode-io/ucode_sdk/function"
+	"github.com/ucode-io/ucode_sdk/ucodetest"
+)
+
+// Values of the -backend flag
+const (
+	backendFake   = "fake"
+	backendRecord = "record:"
+	backendReplay = "replay:"
+)
+
+// startBackend starts the SDK backend chosen with -backend in this process and
+// returns the environment pointing functions created with function.Handle to it.
+// The backend outlives restarts of the function, a fake keeps its data.
+// A recorder only proxies UCODE_BASE_URL, so auth requests keep going to
+// UCODE_BASE_AUTH_URL unrecorded.
+func startBackend(backend string) (env []string, stop func(), err error) {
+	var (
+		url  string
+		auth = true
+	)
+
+	switch {
+	case backend == "":
+		return nil, func() {}, nil
+	case backend == backendFake:
+		srv := ucodetest.NewServer()
+		url, stop = srv.URL, srv.Close
+	case strings.HasPrefix(backend, backendRecord):
+		target := os.Getenv(function.EnvBaseURL)
+		if target == "" {
+			return nil, nil, fmt.Errorf("%s must be set to record interactions", function.EnvBaseURL)
+		}
+		rec := ucodetest.NewRecorder(target, strings.TrimPrefix(backend, backendRecord))
+		url, stop, auth = rec.URL, rec.Close, false
+	case strings.HasPrefix(backend, backendReplay):
+		rep, err := ucodetest.NewReplayer(strings.TrimPrefix(backend, backendReplay))
+		if err != nil {
+			return nil, nil, err
+		}
+		url, stop = rep.URL, rep.Close
+	default:
+		return nil, nil, fmt.Errorf("unknown backend %q", backend)
+	}
+
+	log.Printf("SDK backend %s on %s", backend, url)
+
+	env = []string{function.EnvBaseURL + "=" + url}
+	if auth {
+		env = append(env, function.EnvBaseAuthURL+"="+url)
+	}
+
+	return env, stop, nil
+}
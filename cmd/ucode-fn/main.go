@@ -0,0 +1,297 @@
+// Command ucode-fn runs Ucode functions locally.
+//
+//	ucode-fn serve [-addr ADDR] [-payload FILE] [-backend BACKEND] [-watch=false] [DIR]
+//
+// serve builds the function package in DIR (default ".") which must export
+// Handle() http.HandlerFunc, hosts it with function.Serve and rebuilds and
+// restarts it whenever a .go file changes. -addr and -payload are passed to
+// function.ServeOptions. -backend starts a fake, recording or replaying API in
+// ucode-fn itself and points the function to it with UCODE_BASE_URL, so the
+// fake API is never linked into the function.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const mainTemplate = `// Code generated by ucode-fn. DO NOT EDIT.
+
+package main
+
+import (
+	handler %q
+
+	"github.com/ucode-io/ucode_sdk/function"
+)
+
+func main() {
+	function.ServeMain(handler.Handle())
+}
+`
+
+func main() {
+	log.SetFlags(log.Ltime)
+	log.SetPrefix("ucode-fn: ")
+
+	if len(os.Args) < 2 || os.Args[1] != "serve" {
+		fmt.Fprintln(os.Stderr, "usage: ucode-fn serve [-addr ADDR] [-payload FILE] [-backend fake|record:FILE|replay:FILE] [-watch=false] [DIR]")
+		os.Exit(2)
+	}
+
+	// run returns instead of exiting, so deferred cleanups like removing .ucode-fn run
+	if err := run(os.Args[2:]); err != nil {
+		log.Print(err)
+		os.Exit(1)
+	}
+}
+
+func run(arguments []string) error {
+	var (
+		flags   = flag.NewFlagSet("serve", flag.ExitOnError)
+		addr    = flags.String("addr", "", "address to listen on (default localhost:8080)")
+		payload = flags.String("payload", "", "JSON request file posted to the function after every start")
+		backend = flags.String("backend", "", `SDK backend: "fake", "record:FILE" or "replay:FILE"`)
+		watch   = flags.Bool("watch", true, "rebuild and restart on file change")
+	)
+	flags.Parse(arguments)
+
+	dir := flags.Arg(0)
+	if dir == "" {
+		dir = "."
+	}
+
+	var args []string
+	if *addr != "" {
+		args = append(args, "-addr", *addr)
+	}
+	if *payload != "" {
+		abs, err := filepath.Abs(*payload)
+		if err != nil {
+			return err
+		}
+		args = append(args, "-payload", abs)
+	}
+
+	env, stopBackend, err := startBackend(*backend)
+	if err != nil {
+		return err
+	}
+	defer stopBackend()
+
+	return serve(dir, args, env, *watch)
+}
+
+func serve(dir string, args, env []string, watch bool) error {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+
+	runner, err := newRunner(dir, args, env)
+	if err != nil {
+		return err
+	}
+	defer runner.cleanup()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := runner.restart(); err != nil {
+		log.Print(err)
+	}
+
+	if !watch {
+		<-ctx.Done()
+		runner.stop()
+		return nil
+	}
+
+	fingerprint, _ := sourceFingerprint(dir)
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			runner.stop()
+			return nil
+		case <-ticker.C:
+		}
+
+		current, err := sourceFingerprint(dir)
+		if err != nil || current == fingerprint {
+			continue
+		}
+		fingerprint = current
+
+		log.Print("change detected, rebuilding")
+		if err := runner.restart(); err != nil {
+			log.Print(err)
+		}
+	}
+}
+
+type runner struct {
+	moduleDir string
+	genDir    string
+	binary    string
+	args      []string
+	env       []string
+	cmd       *exec.Cmd
+	done      chan struct{}
+}
+
+func newRunner(dir string, args, env []string) (*runner, error) {
+	importPath, err := goOutput(dir, "list", "-f", "{{.ImportPath}}", ".")
+	if err != nil {
+		return nil, err
+	}
+
+	goMod, err := goOutput(dir, "env", "GOMOD")
+	if err != nil {
+		return nil, err
+	}
+	if goMod == "" || goMod == os.DevNull {
+		return nil, fmt.Errorf("%s is not inside a Go module", dir)
+	}
+
+	var (
+		moduleDir = filepath.Dir(goMod)
+		genDir    = filepath.Join(moduleDir, ".ucode-fn")
+	)
+
+	if err := os.MkdirAll(genDir, 0o755); err != nil {
+		return nil, err
+	}
+
+	err = os.WriteFile(filepath.Join(genDir, "main.go"), []byte(fmt.Sprintf(mainTemplate, importPath)), 0o644)
+	if err != nil {
+		os.RemoveAll(genDir)
+		return nil, err
+	}
+
+	binary := filepath.Join(genDir, "function")
+	if strings.EqualFold(filepath.Ext(os.Args[0]), ".exe") {
+		binary += ".exe"
+	}
+
+	return &runner{
+		moduleDir: moduleDir,
+		genDir:    genDir,
+		binary:    binary,
+		args:      args,
+		env:       env,
+	}, nil
+}
+
+// restart builds the function and replaces the running process. A failed
+// build keeps the previous process running.
+func (r *runner) restart() error {
+	build := exec.Command("go", "build", "-o", r.binary, "./.ucode-fn")
+	build.Dir = r.moduleDir
+	build.Stdout = os.Stderr
+	build.Stderr = os.Stderr
+
+	if err := build.Run(); err != nil {
+		return fmt.Errorf("build failed: %w", err)
+	}
+
+	r.stop()
+
+	cmd := exec.Command(r.binary, r.args...)
+	cmd.Env = append(os.Environ(), r.env...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	done := make(chan struct{})
+	go func() {
+		cmd.Wait()
+		close(done)
+	}()
+
+	r.cmd, r.done = cmd, done
+
+	return nil
+}
+
+func (r *runner) stop() {
+	if r.cmd == nil {
+		return
+	}
+
+	r.cmd.Process.Signal(os.Interrupt)
+
+	select {
+	case <-r.done:
+	case <-time.After(5 * time.Second):
+		r.cmd.Process.Kill()
+		<-r.done
+	}
+
+	r.cmd = nil
+}
+
+func (r *runner) cleanup() {
+	os.RemoveAll(r.genDir)
+}
+
+// sourceFingerprint summarizes names, sizes and modification times of Go
+// sources and module files under dir
+func sourceFingerprint(dir string) (string, error) {
+	var b strings.Builder
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			if path != dir && (strings.HasPrefix(d.Name(), ".") || d.Name() == "vendor") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !strings.HasSuffix(path, ".go") && d.Name() != "go.mod" && d.Name() != "go.sum" {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(&b, "%s:%d:%d;", path, info.Size(), info.ModTime().UnixNano())
+		return nil
+	})
+
+	return b.String(), err
+}
+
+func goOutput(dir string, args ...string) (string, error) {
+	cmd := exec.Command("go", args...)
+	cmd.Dir = dir
+	cmd.Stderr = os.Stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("go %s: %w", strings.Join(args, " "), err)
+	}
+
+	return strings.TrimSpace(string(out)), nil
+}
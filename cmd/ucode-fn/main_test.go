@@ -0,0 +1,114 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	sdk "github.com/ucode-io/ucode_sdk"
+	"github.com/ucode-io/ucode_sdk/function"
+	"github.com/ucode-io/ucode_sdk/ucodetest"
+)
+
+// configFromEnv builds the SDK config a function started with env would get
+func configFromEnv(env []string) *sdk.Config {
+	config := &sdk.Config{}
+	for _, kv := range env {
+		key, value, _ := strings.Cut(kv, "=")
+		switch key {
+		case function.EnvBaseURL:
+			config.BaseURL = value
+		case function.EnvBaseAuthURL:
+			config.BaseAuthUrl = value
+		}
+	}
+	return config
+}
+
+func TestStartBackend(t *testing.T) {
+	env, stop, err := startBackend("")
+	assert.NoError(t, err)
+	assert.Empty(t, env)
+	stop()
+
+	env, stop, err = startBackend(backendFake)
+	assert.NoError(t, err)
+	defer stop()
+
+	// the environment of ucode-fn itself is left alone
+	assert.NotContains(t, os.Getenv(function.EnvBaseURL), "127.0.0.1")
+
+	api := sdk.New(configFromEnv(env))
+
+	created, _, err := api.Items("order").Create(map[string]any{"title": "first"}).Exec()
+	assert.NoError(t, err)
+	assert.NotEmpty(t, created.Data.Data["guid"])
+
+	_, _, err = startBackend("remote")
+	assert.ErrorContains(t, err, `unknown backend "remote"`)
+
+	_, _, err = startBackend(backendReplay + filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
+
+func TestStartBackendRecordReplay(t *testing.T) {
+	target := ucodetest.NewServer()
+	defer target.Close()
+	target.Seed("order", map[string]any{"guid": "1", "title": "first"})
+
+	cassette := filepath.Join(t.TempDir(), "api.json")
+
+	t.Setenv(function.EnvBaseURL, "")
+	_, _, err := startBackend(backendRecord + cassette)
+	assert.ErrorContains(t, err, function.EnvBaseURL)
+
+	t.Setenv(function.EnvBaseURL, target.URL)
+	env, stop, err := startBackend(backendRecord + cassette)
+	assert.NoError(t, err)
+
+	// the recorder only proxies the API, auth keeps its own URL
+	assert.Empty(t, configFromEnv(env).BaseAuthUrl)
+
+	recorded, _, err := sdk.New(configFromEnv(env)).Items("order").GetSingle("1").Exec()
+	assert.NoError(t, err)
+	stop()
+
+	// the replay doesn't need the target anymore
+	target.Close()
+
+	env, stop, err = startBackend(backendReplay + cassette)
+	assert.NoError(t, err)
+	defer stop()
+
+	replayed, _, err := sdk.New(configFromEnv(env)).Items("order").GetSingle("1").Exec()
+	assert.NoError(t, err)
+	assert.Equal(t, recorded, replayed)
+	assert.Equal(t, "first", replayed.Data.Data.Response["title"])
+}
+
+func TestSourceFingerprint(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "handler.go"), []byte("package handler"), 0o644))
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, ".ucode-fn"), 0o755))
+
+	before, err := sourceFingerprint(dir)
+	assert.NoError(t, err)
+
+	// generated files and non Go files don't trigger a rebuild
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, ".ucode-fn", "main.go"), []byte("package main"), 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("notes"), 0o644))
+
+	after, err := sourceFingerprint(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, before, after)
+
+	later := time.Now().Add(time.Second)
+	assert.NoError(t, os.Chtimes(filepath.Join(dir, "handler.go"), later, later))
+
+	after, err = sourceFingerprint(dir)
+	assert.NoError(t, err)
+	assert.NotEqual(t, before, after)
+}
@@ -1,7 +1,10 @@
 package ucodesdk
 
 import (
+	"log/slog"
 	"time"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Config struct {
@@ -14,4 +17,45 @@ type Config struct {
 	MQTTBroker     string
 	MQTTUsername   string
 	MQTTPassword   string
+	// MQTTClientID identifies the connection, the broker generates one if empty
+	MQTTClientID string
+	// MQTTCAFile, MQTTCertFile and MQTTKeyFile enable TLS, use an ssl:// broker url
+	MQTTCAFile   string
+	MQTTCertFile string
+	MQTTKeyFile  string
+	// MQTTPersistentSession keeps subscriptions and queued messages on the broker while disconnected
+	MQTTPersistentSession bool
+	// MQTTKeepAlive is the ping interval, default 30s
+	MQTTKeepAlive time.Duration
+	// MQTTMaxReconnectInterval limits the reconnect backoff, default 1m
+	MQTTMaxReconnectInterval time.Duration
+	// MQTTOnStateChange is called when the shared connection is established, lost or reconnecting
+	MQTTOnStateChange func(state MQTTState, err error)
+	// MQTTQueuePath enables the offline queue: messages published while the connection
+	// is down are stored in this file and replayed in order after reconnect
+	MQTTQueuePath string
+	// MQTTQueueSize limits the offline queue, default 1000
+	MQTTQueueSize int
+	// MQTTQueueDropPolicy decides what happens when the queue is full, default MQTTDropOldest
+	MQTTQueueDropPolicy MQTTDropPolicy
+	// FileSigningSecret makes Files().SignedURL sign links locally with HMAC
+	// instead of asking the server, see VerifySignedURL.
+	FileSigningSecret string
+	// FileSigningBaseURL is the base of locally signed links, usually your own
+	// file proxy. Defaults to BaseURL + "/v1/files".
+	FileSigningBaseURL string
+	// FunctionSigningSecret signs every function invocation, see APIFunction.Sign
+	FunctionSigningSecret string
+	// Logger receives a debug record for every request and an error record for
+	// failed ones and for RPC replies that couldn't be published. API keys,
+	// passwords and tokens are redacted.
+	Logger *slog.Logger
+	// TracerProvider enables a span for every request, named after the operation
+	// like items.create or files.upload. The trace context is propagated in traceparent headers.
+	TracerProvider trace.TracerProvider
+	// Metrics receives request counts, latencies, retries and uploaded bytes, see the metrics package
+	Metrics Metrics
+	// MaxRetries retries GET requests failing with a network error, 429 or 5xx, default 0.
+	// Writes are never retried.
+	MaxRetries int
 }
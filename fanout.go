@@ -0,0 +1,159 @@
+package ucodesdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+type FunctionCall struct {
+	Path string
+	Data map[string]any
+}
+
+type FanOutResult struct {
+	Call     FunctionCall
+	Response FunctionResponse
+	Err      error
+}
+
+// FunctionCalls builds calls of one function with each of payloads
+func FunctionCalls(path string, payloads ...map[string]any) []FunctionCall {
+	calls := make([]FunctionCall, 0, len(payloads))
+	for _, data := range payloads {
+		calls = append(calls, FunctionCall{Path: path, Data: data})
+	}
+	return calls
+}
+
+func (u *object) FanOut(calls ...FunctionCall) *FanOut {
+	return &FanOut{
+		object:      u,
+		calls:       calls,
+		concurrency: 4,
+	}
+}
+
+func (f *FanOut) Concurrency(n int) *FanOut {
+	if n > 0 {
+		f.concurrency = n
+	}
+	return f
+}
+
+// FailFast stops starting new calls and cancels running ones after the first failure
+func (f *FanOut) FailFast(failFast bool) *FanOut {
+	f.failFast = failFast
+	return f
+}
+
+func (f *FanOut) Context(ctx context.Context) *FanOut {
+	f.ctx = ctx
+	return f
+}
+
+// Configure is applied to every call before it is executed, e.g. to set headers or timeouts
+func (f *FanOut) Configure(configure func(*APIFunction) *APIFunction) *FanOut {
+	f.configure = configure
+	return f
+}
+
+/*
+Exec invokes all calls and returns their results in the order of calls.
+
+A call fails when the request fails or the function responds with status "error"
+(Err is a *FunctionError then). The returned error joins the errors of all failed
+calls, with FailFast only the first failure is returned and calls that didn't run
+have context.Canceled as Err.
+*/
+func (f *FanOut) Exec() ([]FanOutResult, Response, error) {
+	var (
+		response = Response{Status: "done"}
+		results  = make([]FanOutResult, len(f.calls))
+		parent   = f.ctx
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+		jobs     = make(chan int)
+	)
+
+	if parent == nil {
+		parent = context.Background()
+	}
+
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+
+	for i := 0; i < f.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				results[idx] = f.invoke(ctx, f.calls[idx])
+
+				if results[idx].Err != nil && f.failFast {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = results[idx].Err
+					}
+					mu.Unlock()
+					cancel()
+				}
+			}
+		}()
+	}
+
+	for i, call := range f.calls {
+		results[i].Call = call
+		if ctx.Err() != nil {
+			results[i].Err = ctx.Err()
+			continue
+		}
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	var (
+		errs   []error
+		failed int
+	)
+
+	for _, result := range results {
+		if result.Err != nil {
+			failed++
+			errs = append(errs, fmt.Errorf("%s: %w", result.Call.Path, result.Err))
+		}
+	}
+
+	if failed == 0 {
+		return results, response, nil
+	}
+
+	err := errors.Join(errs...)
+	if f.failFast && firstErr != nil {
+		err = firstErr
+	}
+
+	response.Data = map[string]any{"message": fmt.Sprintf("%d of %d function calls failed", failed, len(f.calls)), "error": err.Error()}
+	response.Status = "error"
+
+	return results, response, err
+}
+
+func (f *FanOut) invoke(ctx context.Context, call FunctionCall) FanOutResult {
+	result := FanOutResult{Call: call}
+
+	fn := f.object.Function(call.Path).Invoke(call.Data).Context(ctx)
+	if f.configure != nil {
+		fn = f.configure(fn)
+	}
+
+	result.Response, _, result.Err = fn.Exec()
+	if result.Err == nil {
+		result.Err = result.Response.Err(call.Path)
+	}
+
+	return result
+}
@@ -2,12 +2,15 @@ package ucodesdk
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"mime/multipart"
 	"net/http"
+	nurl "net/url"
 	"os"
+	"time"
 )
 
 func (u *object) Files() FilesI {
@@ -41,13 +44,99 @@ type FilesI interface {
 		This method removes a file based on its unique identifier, allowing for clean file management.
 	*/
 	Delete(fileID string) *DeleteFile
+	/*
+		UploadDir is a function that uploads every file of a local directory to the server.
+
+		Works for [Mongo, Postgres]
+
+		sdk.Files().
+			UploadDir("dir_path", ucodesdk.UploadDirOptions{
+				Include:      []string{"*.png", "*.svg"},
+				Exclude:      []string{"tmp"},
+				Concurrency:  8,
+				ManifestPath: ".ucode-manifest.json",
+			}).
+			Exec()
+
+		Relative paths are kept as folder names, unchanged files recorded in the manifest are skipped
+		and the result contains a report for every file.
+	*/
+	UploadDir(dir string, opts UploadDirOptions) *UploadDir
+	/*
+		DeleteMany is a function that deletes several files from the server concurrently.
+
+		Works for [Mongo, Postgres]
+
+		sdk.Files().
+			DeleteMany([]string{"file_id_1", "file_id_2"}).
+			Concurrency(8). //default 4
+			Exec()
+
+		Files that couldn't be deleted are listed in the Failed field of the result.
+	*/
+	DeleteMany(fileIDs []string) *DeleteManyFiles
+	/*
+		FindOrphans is a function that finds uploaded files which no item references.
+
+		Works for [Mongo, Postgres]
+
+		sdk.Files().
+			FindOrphans(map[string][]string{"order": {"invoice", "photos"}}).
+			FolderName("Media").
+			Delete(false). //default false, only report
+			Exec()
+
+		references maps collection names to fields holding file links. Every item of
+		the collections is scanned, so run it from maintenance jobs rather than requests.
+		The scan fails if a collection can't be listed or a field is missing from all of its items,
+		and Delete refuses to run when no references were found unless AllowNoReferences(true) is set.
+
+		Files are listed with GET /v1/files?folder_name=&offset=&limit=, which isn't part of the
+		documented Ucode API: the backend must serve it (ucodetest does), otherwise the scan fails.
+	*/
+	FindOrphans(references map[string][]string) *FindOrphanFiles
+	/*
+		SignedURL is a function that creates a time-limited link to a private file.
+
+		Works for [Mongo, Postgres]
+
+		sdk.Files().
+			SignedURL("file_id", 15*time.Minute).
+			Exec()
+
+		The link is signed locally when Config.FileSigningSecret is set, locally signed links
+		are checked with VerifySignedURL. Otherwise it is requested from
+		GET /v1/files/{id}/signed-url?expires_in=, an endpoint outside the documented Ucode API
+		that the backend must provide (ucodetest does).
+	*/
+	SignedURL(fileID string, ttl time.Duration) *SignedURL
 }
 
 func (f *APIFiles) Upload(filePath string) *UploadFile {
 	return &UploadFile{
-		config: f.config,
-		path:   filePath,
+		config:     f.config,
+		path:       filePath,
+		folderName: "Media",
+	}
+}
+
+func (c *UploadFile) FolderName(name string) *UploadFile {
+	if name != "" {
+		c.folderName = name
 	}
+	return c
+}
+
+// Dedup makes Exec return the file already uploaded with the same content into
+// the same folder instead of uploading it again. Successful uploads are added to the cache.
+func (c *UploadFile) Dedup(cache FileDedupCache) *UploadFile {
+	c.dedup = cache
+	return c
+}
+
+func (c *UploadFile) Context(ctx context.Context) *UploadFile {
+	c.ctx = ctx
+	return c
 }
 
 func (c *UploadFile) Exec() (CreateFileResponse, Response, error) {
@@ -55,11 +144,27 @@ func (c *UploadFile) Exec() (CreateFileResponse, Response, error) {
 		file          *os.File
 		fileBuffer    bytes.Buffer
 		writer        *multipart.Writer
+		checksums     = newChecksumWriter()
 		response      = Response{Status: "done"}
 		createdObject CreateFileResponse
-		url           = fmt.Sprintf("%s/v1/files/folder_upload?folder_name=Media", c.config.BaseURL)
+		url           = fmt.Sprintf("%s/v1/files/folder_upload?folder_name=%s", c.config.BaseURL, nurl.QueryEscape(c.folderName))
 	)
 
+	if c.dedup != nil {
+		sums, err := fileChecksums(c.path)
+		if err != nil {
+			response.Data = map[string]any{"description": string(c.path), "message": "can't read file by path", "error": err.Error()}
+			response.Status = "error"
+			return CreateFileResponse{}, response, err
+		}
+
+		if existing, ok := c.dedup.Get(FileDedupKey(c.folderName, sums.SHA256)); ok {
+			existing.Checksums = sums
+			response.Data = map[string]any{"deduplicated": true}
+			return existing, response, nil
+		}
+	}
+
 	file, err := os.Open(c.path)
 	if err != nil {
 		response.Data = map[string]any{"description": string(c.path), "message": "can't open file by path", "error": err.Error()}
@@ -76,7 +181,7 @@ func (c *UploadFile) Exec() (CreateFileResponse, Response, error) {
 		return CreateFileResponse{}, response, err
 	}
 
-	_, err = io.Copy(part, file)
+	fileSize, err := io.Copy(part, io.TeeReader(file, checksums))
 	if err != nil {
 		response.Data = map[string]any{"description": string(c.path), "message": "can't copy file", "error": err.Error()}
 		response.Status = "error"
@@ -97,7 +202,7 @@ func (c *UploadFile) Exec() (CreateFileResponse, Response, error) {
 		"X-API-KEY":     appId,
 	}
 
-	createFileInByte, err := DoFileRequest(url, http.MethodPost, header, fileBuffer, writer)
+	createFileInByte, err := doFileRequest(orBackground(c.ctx), c.config, requestInfo{operation: "files.upload"}, url, http.MethodPost, header, &fileBuffer, writer.FormDataContentType(), fileSize)
 	if err != nil {
 		response.Data = map[string]any{"description": string(createFileInByte), "message": "Can't send request", "error": err.Error()}
 		response.Status = "error"
@@ -111,6 +216,25 @@ func (c *UploadFile) Exec() (CreateFileResponse, Response, error) {
 		return CreateFileResponse{}, response, err
 	}
 
+	createdObject.Checksums = checksums.Sum()
+
+	err = verifyUploadedFile(createdObject.Checksums, createdObject)
+	if err != nil {
+		response.Data = map[string]any{"description": string(createFileInByte), "message": "Uploaded file doesn't match local file", "error": err.Error()}
+		response.Status = "error"
+		return createdObject, response, err
+	}
+
+	// an error body decodes without an id, caching it would dedup later uploads to nothing
+	if c.dedup != nil && createdObject.Data.ID != "" {
+		err = c.dedup.Set(FileDedupKey(c.folderName, createdObject.Checksums.SHA256), createdObject)
+		if err != nil {
+			response.Data = map[string]any{"description": string(c.path), "message": "Error while saving file to dedup cache", "error": err.Error()}
+			response.Status = "error"
+			return createdObject, response, err
+		}
+	}
+
 	return createdObject, response, nil
 }
 
@@ -121,10 +245,15 @@ func (f *APIFiles) Delete(fileID string) *DeleteFile {
 	}
 }
 
+func (a *DeleteFile) Context(ctx context.Context) *DeleteFile {
+	a.ctx = ctx
+	return a
+}
+
 func (a *DeleteFile) Exec() (Response, error) {
 	var (
 		response = Response{Status: "done"}
-		url      = fmt.Sprintf("%s/v1/files/%s", a.config.BaseURL, a.id)
+		url      = fmt.Sprintf("%s/v1/files/%s", a.config.BaseURL, nurl.PathEscape(a.id))
 	)
 
 	var appId = a.config.AppId
@@ -134,7 +263,7 @@ func (a *DeleteFile) Exec() (Response, error) {
 		"X-API-KEY":     appId,
 	}
 
-	_, err := DoRequest(url, http.MethodDelete, Request{Data: map[string]any{}}, header)
+	_, err := doCheckedRequest(orBackground(a.ctx), a.config, requestInfo{operation: "files.delete"}, url, http.MethodDelete, Request{Data: map[string]any{}}, header)
 	if err != nil {
 		response.Data = map[string]any{"message": "Error while deleting file", "error": err.Error()}
 		response.Status = "error"
@@ -144,26 +273,15 @@ func (a *DeleteFile) Exec() (Response, error) {
 	return response, nil
 }
 
+// DoFileRequest sends a multipart body and returns the response body whatever
+// its status. It has no Config to report to, the DoFileRequest method of
+// UcodeApis sends the same request with logging, tracing and metrics.
 func DoFileRequest(url, method string, headers map[string]string, body bytes.Buffer, writer *multipart.Writer) ([]byte, error) {
-	request, err := http.NewRequest(method, url, &body)
-	if err != nil {
-		return nil, err
-	}
-
-	for key, value := range headers {
-		request.Header.Add(key, value)
-	}
-
-	request.Header.Set("Content-Type", writer.FormDataContentType())
-
-	client := &http.Client{}
-	resp, err := client.Do(request)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	respByte, err := io.ReadAll(resp.Body)
+	result, err := sendFile(context.Background(), &Config{}, requestInfo{operation: "file_request"}, url, method, headers, &body, writer.FormDataContentType(), int64(body.Len()))
+	return result.body, err
+}
 
-	return respByte, err
+func (a *object) DoFileRequest(url, method string, headers map[string]string, body bytes.Buffer, writer *multipart.Writer) ([]byte, error) {
+	result, err := sendFile(context.Background(), a.config, requestInfo{operation: "file_request"}, url, method, headers, &body, writer.FormDataContentType(), int64(body.Len()))
+	return result.body, err
 }
@@ -0,0 +1,295 @@
+package ucodesdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	nurl "net/url"
+	"path"
+	"sync"
+)
+
+func (f *APIFiles) DeleteMany(fileIDs []string) *DeleteManyFiles {
+	return &DeleteManyFiles{
+		files:       f,
+		ids:         fileIDs,
+		concurrency: 4,
+	}
+}
+
+func (d *DeleteManyFiles) Concurrency(n int) *DeleteManyFiles {
+	if n > 0 {
+		d.concurrency = n
+	}
+	return d
+}
+
+// Context sets the context the deletions run with, files not deleted when it's canceled end up in Failed
+func (d *DeleteManyFiles) Context(ctx context.Context) *DeleteManyFiles {
+	d.ctx = ctx
+	return d
+}
+
+func (d *DeleteManyFiles) Exec() (DeleteManyFilesResponse, Response, error) {
+	var (
+		ctx      = orBackground(d.ctx)
+		response = Response{Status: "done"}
+		result   = DeleteManyFilesResponse{Failed: map[string]string{}}
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		jobs     = make(chan string)
+	)
+
+	if len(d.ids) == 0 {
+		response.Data = map[string]any{"message": "Error while deleting files", "error": "ids is empty"}
+		response.Status = "error"
+		return result, response, fmt.Errorf("ids is empty")
+	}
+
+	for i := 0; i < d.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := range jobs {
+				_, err := d.files.Delete(id).Context(ctx).Exec()
+
+				mu.Lock()
+				if err != nil {
+					result.Failed[id] = err.Error()
+				} else {
+					result.Deleted = append(result.Deleted, id)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, id := range d.ids {
+		jobs <- id
+	}
+	close(jobs)
+	wg.Wait()
+
+	if len(result.Failed) > 0 {
+		err := fmt.Errorf("%d of %d files failed to delete", len(result.Failed), len(d.ids))
+		response.Data = map[string]any{"message": "Error while deleting files", "error": err.Error(), "failed": result.Failed}
+		response.Status = "error"
+		return result, response, err
+	}
+
+	return result, response, nil
+}
+
+func (f *APIFiles) FindOrphans(references map[string][]string) *FindOrphanFiles {
+	return &FindOrphanFiles{
+		files:       f,
+		references:  references,
+		concurrency: 4,
+	}
+}
+
+// FolderName limits the scan to files uploaded into folder
+func (o *FindOrphanFiles) FolderName(name string) *FindOrphanFiles {
+	o.folderName = name
+	return o
+}
+
+// Delete removes found orphans, otherwise they are only reported
+func (o *FindOrphanFiles) Delete(del bool) *FindOrphanFiles {
+	o.delete = del
+	return o
+}
+
+// AllowNoReferences lets Delete remove files when the referencing collections hold
+// no links at all, which otherwise is treated as a misconfigured scan
+func (o *FindOrphanFiles) AllowNoReferences(allow bool) *FindOrphanFiles {
+	o.allowNoReferences = allow
+	return o
+}
+
+func (o *FindOrphanFiles) Concurrency(n int) *FindOrphanFiles {
+	if n > 0 {
+		o.concurrency = n
+	}
+	return o
+}
+
+// Context sets the context both the scan and the deletion of orphans run with
+func (o *FindOrphanFiles) Context(ctx context.Context) *FindOrphanFiles {
+	o.ctx = ctx
+	return o
+}
+
+func (o *FindOrphanFiles) Exec() (OrphanFilesResponse, Response, error) {
+	var (
+		ctx      = orBackground(o.ctx)
+		response = Response{Status: "done"}
+		result   OrphanFilesResponse
+		links    = map[string]bool{}
+	)
+
+	// files are listed before references are collected, so a file uploaded and
+	// attached during the scan is either not listed or its reference is seen
+	files, err := o.listFiles(ctx)
+	if err != nil {
+		response.Data = map[string]any{"message": "Error while getting list of files", "error": err.Error()}
+		response.Status = "error"
+		return OrphanFilesResponse{}, response, err
+	}
+	result.Scanned = len(files)
+
+	for collection, fields := range o.references {
+		err := o.collectLinks(ctx, collection, fields, links)
+		if err != nil {
+			response.Data = map[string]any{"description": collection, "message": "Error while getting referenced files", "error": err.Error()}
+			response.Status = "error"
+			return OrphanFilesResponse{}, response, err
+		}
+	}
+	result.References = len(links)
+
+	for _, file := range files {
+		if !isFileReferenced(file, links) {
+			result.Orphans = append(result.Orphans, file)
+		}
+	}
+
+	if !o.delete || len(result.Orphans) == 0 {
+		return result, response, nil
+	}
+
+	if len(links) == 0 && !o.allowNoReferences {
+		err = fmt.Errorf("no file references found, refusing to delete %d files", len(result.Orphans))
+		response.Data = map[string]any{"message": "Error while deleting orphan files", "error": err.Error()}
+		response.Status = "error"
+		return result, response, err
+	}
+
+	ids := make([]string, 0, len(result.Orphans))
+	for _, file := range result.Orphans {
+		ids = append(ids, file.ID)
+	}
+
+	deleted, deleteResponse, err := o.files.DeleteMany(ids).Concurrency(o.concurrency).Context(ctx).Exec()
+	result.Deleted = deleted.Deleted
+	result.Failed = deleted.Failed
+
+	return result, deleteResponse, err
+}
+
+// collectLinks stores every string value of fields in all items of collection
+// and the base name of its path, so links are matched regardless of host.
+// A field no item has is most likely misspelled and fails the scan.
+func (o *FindOrphanFiles) collectLinks(ctx context.Context, collection string, fields []string, links map[string]bool) error {
+	var (
+		items   = &APIItem{collection: collection, config: o.files.config}
+		limit   = 100
+		scanned = 0
+		found   = map[string]bool{}
+	)
+
+	for page := 1; ; page++ {
+		list, _, err := items.GetList().Page(page).Limit(limit).CheckStatus(true).Context(ctx).Exec()
+		if err != nil {
+			return err
+		}
+
+		for _, item := range list.Data.Data.Response {
+			for _, field := range fields {
+				value, ok := item[field]
+				if ok {
+					found[field] = true
+				}
+				addFileLinks(value, links)
+			}
+		}
+		scanned += len(list.Data.Data.Response)
+
+		count := int(list.Data.Data.Count)
+		if len(list.Data.Data.Response) < limit || (count > 0 && page*limit >= count) {
+			break
+		}
+	}
+
+	if scanned == 0 {
+		return nil
+	}
+
+	for _, field := range fields {
+		if !found[field] {
+			return fmt.Errorf("field %s not found in items of %s", field, collection)
+		}
+	}
+
+	return nil
+}
+
+func addFileLinks(value any, links map[string]bool) {
+	switch v := value.(type) {
+	case string:
+		if v == "" {
+			return
+		}
+		links[v] = true
+		links[linkBaseName(v)] = true
+	case []any:
+		for _, elem := range v {
+			addFileLinks(elem, links)
+		}
+	case []string:
+		for _, elem := range v {
+			addFileLinks(elem, links)
+		}
+	}
+}
+
+func isFileReferenced(file FileObject, links map[string]bool) bool {
+	return links[file.Link] ||
+		(file.ID != "" && links[file.ID]) ||
+		(file.FileNameDisk != "" && links[path.Base(file.FileNameDisk)]) ||
+		(file.Link != "" && links[linkBaseName(file.Link)])
+}
+
+func linkBaseName(link string) string {
+	if u, err := nurl.Parse(link); err == nil && u.Path != "" {
+		return path.Base(u.Path)
+	}
+	return path.Base(link)
+}
+
+func (o *FindOrphanFiles) listFiles(ctx context.Context) ([]FileObject, error) {
+	var (
+		files []FileObject
+		limit = 100
+		appId = o.files.config.AppId
+	)
+
+	header := map[string]string{
+		"authorization": "API-KEY",
+		"X-API-KEY":     appId,
+	}
+
+	for offset := 0; ; offset += limit {
+		var (
+			list FileListResponse
+			url  = fmt.Sprintf("%s/v1/files?folder_name=%s&offset=%d&limit=%d", o.files.config.BaseURL, nurl.QueryEscape(o.folderName), offset, limit)
+		)
+
+		listInByte, err := doCheckedRequest(ctx, o.files.config, requestInfo{operation: "files.list"}, url, http.MethodGet, nil, header)
+		if err != nil {
+			return nil, err
+		}
+
+		err = json.Unmarshal(listInByte, &list)
+		if err != nil {
+			return nil, err
+		}
+
+		files = append(files, list.Data.Files...)
+
+		if len(list.Data.Files) < limit || (list.Data.Count > 0 && len(files) >= list.Data.Count) {
+			return files, nil
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package ucodesdk
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fileCleanupServer serves the items list of order, the file list and file deletion
+type fileCleanupServer struct {
+	mu       sync.Mutex
+	files    []FileObject
+	orders   []map[string]any
+	deleted  []string
+	listFail bool
+	// onItems runs before the items of order are served
+	onItems func()
+}
+
+func (s *fileCleanupServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/v2/items/order":
+		if s.onItems != nil {
+			s.onItems()
+		}
+		json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"data": map[string]any{"count": len(s.orders), "response": s.orders}}})
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/v2/items/"):
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]any{"status": "error", "description": "table not found"})
+	case r.Method == http.MethodGet && r.URL.Path == "/v1/files":
+		if s.listFail {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		var list FileListResponse
+		list.Data.Files = s.files
+		list.Data.Count = len(s.files)
+		json.NewEncoder(w).Encode(list)
+	case r.Method == http.MethodDelete:
+		id := strings.TrimPrefix(r.URL.Path, "/v1/files/")
+		for i, file := range s.files {
+			if file.ID == id {
+				s.files = append(s.files[:i], s.files[i+1:]...)
+				s.deleted = append(s.deleted, id)
+				json.NewEncoder(w).Encode(map[string]any{"status": "OK"})
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]any{"status": "error", "description": "file not found"})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (s *fileCleanupServer) update(fn func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn()
+}
+
+func (s *fileCleanupServer) deletedIDs() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.deleted...)
+}
+
+func newFileCleanupServer() *fileCleanupServer {
+	return &fileCleanupServer{
+		files: []FileObject{
+			{ID: "f1", Link: "https://cdn.test/media/f1_invoice.pdf"},
+			{ID: "f2", Link: "https://cdn.test/media/f2_photo.png"},
+		},
+		orders: []map[string]any{
+			{"guid": "o1", "invoice": "https://other.cdn/media/f1_invoice.pdf"},
+		},
+	}
+}
+
+func TestDeleteManyFiles(t *testing.T) {
+	fake := newFileCleanupServer()
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	api := New(&Config{BaseURL: server.URL})
+
+	result, response, err := api.Files().DeleteMany([]string{"f1", "missing", "f2"}).Concurrency(2).Exec()
+	assert.Error(t, err)
+	assert.Equal(t, "error", response.Status)
+
+	sort.Strings(result.Deleted)
+	assert.Equal(t, []string{"f1", "f2"}, result.Deleted)
+	assert.Len(t, result.Failed, 1)
+	assert.Contains(t, result.Failed["missing"], "status 404")
+
+	_, _, err = api.Files().DeleteMany(nil).Exec()
+	assert.Error(t, err)
+}
+
+func TestFindOrphanFiles(t *testing.T) {
+	fake := newFileCleanupServer()
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	api := New(&Config{BaseURL: server.URL})
+
+	result, _, err := api.Files().FindOrphans(map[string][]string{"order": {"invoice"}}).Exec()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, result.Scanned)
+	assert.Len(t, result.Orphans, 1)
+	assert.Equal(t, "f2", result.Orphans[0].ID)
+	assert.Empty(t, fake.deletedIDs())
+
+	result, _, err = api.Files().FindOrphans(map[string][]string{"order": {"invoice"}}).Delete(true).Exec()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"f2"}, result.Deleted)
+	assert.Equal(t, []string{"f2"}, fake.deletedIDs())
+}
+
+func TestFindOrphanFilesAttachedDuringScan(t *testing.T) {
+	fake := newFileCleanupServer()
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	// a file is uploaded and attached while the references are collected
+	fake.onItems = func() {
+		if len(fake.files) == 2 {
+			fake.files = append(fake.files, FileObject{ID: "f3", Link: "https://cdn.test/media/f3_receipt.pdf"})
+			fake.orders = append(fake.orders, map[string]any{"guid": "o2", "invoice": "https://cdn.test/media/f3_receipt.pdf"})
+		}
+	}
+
+	api := New(&Config{BaseURL: server.URL})
+
+	result, _, err := api.Files().FindOrphans(map[string][]string{"order": {"invoice"}}).Delete(true).Exec()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"f2"}, result.Deleted)
+	assert.Equal(t, []string{"f2"}, fake.deletedIDs())
+}
+
+func TestFindOrphanFilesFailures(t *testing.T) {
+	fake := newFileCleanupServer()
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	api := New(&Config{BaseURL: server.URL})
+
+	// a misspelled collection fails instead of making every file an orphan
+	_, response, err := api.Files().FindOrphans(map[string][]string{"oder": {"invoice"}}).Delete(true).Exec()
+	var statusErr *StatusError
+	assert.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, http.StatusNotFound, statusErr.StatusCode)
+	assert.Equal(t, "error", response.Status)
+
+	// so does a misspelled field
+	_, _, err = api.Files().FindOrphans(map[string][]string{"order": {"invoce"}}).Delete(true).Exec()
+	assert.ErrorContains(t, err, "field invoce not found")
+
+	fake.update(func() { fake.listFail = true })
+	_, _, err = api.Files().FindOrphans(map[string][]string{"order": {"invoice"}}).Delete(true).Exec()
+	assert.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, http.StatusInternalServerError, statusErr.StatusCode)
+	fake.update(func() { fake.listFail = false })
+
+	// no references at all deletes nothing unless allowed
+	fake.update(func() { fake.orders = []map[string]any{{"guid": "o1", "invoice": ""}} })
+
+	result, _, err := api.Files().FindOrphans(map[string][]string{"order": {"invoice"}}).Delete(true).Exec()
+	assert.ErrorContains(t, err, "refusing to delete 2 files")
+	assert.Len(t, result.Orphans, 2)
+	assert.Empty(t, fake.deletedIDs())
+
+	result, _, err = api.Files().FindOrphans(map[string][]string{"order": {"invoice"}}).Delete(true).AllowNoReferences(true).Exec()
+	assert.NoError(t, err)
+	assert.Len(t, result.Deleted, 2)
+}
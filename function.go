@@ -1,47 +1,127 @@
 package ucodesdk
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	nurl "net/url"
+	"time"
 )
 
 func (u *object) Function(path string) FunctionI {
 	return &APIFunction{
-		config: u.config,
-		path:   path,
+		config:        u.config,
+		path:          path,
+		timeout:       u.config.RequestTimeout,
+		signingSecret: u.config.FunctionSigningSecret,
 	}
 }
 
 // Function interface defines methods for invoking functions
 type FunctionI interface {
+	/*
+		Invoke is a function that prepares a call of the function with the given data.
+
+		sdk.Function("function_path").
+			Invoke(data).
+			Headers(map[string]string{"X-Request-Id": "123"}).
+			QueryParams(map[string]string{"lang": "en"}).
+			IsCached(true).
+			Timeout(10 * time.Second). //default Config.RequestTimeout
+			Exec()
+	*/
 	Invoke(data map[string]any) *APIFunction
+	/*
+		InvokeAsync is a function that starts the function and returns a job handle without waiting for the result.
+
+		job, err := sdk.Function("function_path").
+			InvokeAsync(data)
+
+		result, err := job.Wait(ctx) // or job.Status(ctx), job.Cancel(ctx)
+
+		Use it for long-running functions instead of holding the connection open until the timeout.
+	*/
+	InvokeAsync(data map[string]any) (*FunctionJob, error)
 }
 
 // APIFunction struct implements FunctionInterface
 
 func (f *APIFunction) Invoke(data map[string]any) *APIFunction {
-	return &APIFunction{
-		config:  f.config,
-		request: Request{Data: data},
+	invoke := *f
+	invoke.request = Request{Data: data, IsCached: f.request.IsCached}
+	invoke.headers = copyStringMap(f.headers)
+	invoke.query = copyStringMap(f.query)
+	return &invoke
+}
+
+// Headers adds custom headers to the invoke request, they override the default ones
+func (f *APIFunction) Headers(headers map[string]string) *APIFunction {
+	if f.headers == nil {
+		f.headers = map[string]string{}
 	}
+	for key, value := range headers {
+		f.headers[key] = value
+	}
+	return f
+}
+
+func (f *APIFunction) QueryParams(params map[string]string) *APIFunction {
+	if f.query == nil {
+		f.query = map[string]string{}
+	}
+	for key, value := range params {
+		f.query[key] = value
+	}
+	return f
+}
+
+func (f *APIFunction) IsCached(isCached bool) *APIFunction {
+	f.request.IsCached = isCached
+	return f
+}
+
+// Timeout limits the duration of a single Exec, zero means no limit
+func (f *APIFunction) Timeout(timeout time.Duration) *APIFunction {
+	f.timeout = timeout
+	return f
+}
+
+// Sign adds signature headers created with secret, see SignatureVerifier.
+// Defaults to Config.FunctionSigningSecret.
+func (f *APIFunction) Sign(secret string) *APIFunction {
+	f.signingSecret = secret
+	return f
+}
+
+// Context sets the context Exec and ExecAsync invoke the function with. A FunctionJob is polled with the context passed to Wait.
+func (f *APIFunction) Context(ctx context.Context) *APIFunction {
+	f.ctx = ctx
+	return f
 }
 
 func (f *APIFunction) Exec() (FunctionResponse, Response, error) {
 	var (
 		response     = Response{Status: "done"}
 		invokeObject FunctionResponse
-		url          = fmt.Sprintf("%s/v1/invoke_function/%s", f.config.BaseURL, f.path)
+		url          = f.url("")
+		ctx          = orBackground(f.ctx)
 	)
 
-	var appId = f.config.AppId
+	if f.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, f.timeout)
+		defer cancel()
+	}
 
-	header := map[string]string{
-		"authorization": "API-KEY",
-		"X-API-KEY":     appId,
+	body, header, err := f.signedBody()
+	if err != nil {
+		response.Data = map[string]any{"message": "Error while marshalling invoke function request", "error": err.Error()}
+		response.Status = "error"
+		return FunctionResponse{}, response, err
 	}
 
-	invokeFunctionResponseInByte, err := DoRequest(url, http.MethodPost, f.request, header)
+	invokeFunctionResponseInByte, err := doRequest(ctx, f.config, requestInfo{operation: "function.invoke"}, url, http.MethodPost, body, header)
 	if err != nil {
 		response.Data = map[string]any{"description": string(invokeFunctionResponseInByte), "message": "Can't send request", "error": err.Error()}
 		response.Status = "error"
@@ -57,3 +137,61 @@ func (f *APIFunction) Exec() (FunctionResponse, Response, error) {
 
 	return invokeObject, response, nil
 }
+
+func (f *APIFunction) url(suffix string) string {
+	url := fmt.Sprintf("%s/v1/invoke_function/%s%s", f.config.BaseURL, nurl.PathEscape(f.path), suffix)
+
+	if len(f.query) > 0 {
+		query := nurl.Values{}
+		for key, value := range f.query {
+			query.Set(key, value)
+		}
+		url += "?" + query.Encode()
+	}
+
+	return url
+}
+
+func (f *APIFunction) header() map[string]string {
+	header := map[string]string{
+		"authorization": "API-KEY",
+		"X-API-KEY":     f.config.AppId,
+	}
+
+	for key, value := range f.headers {
+		header[key] = value
+	}
+
+	return header
+}
+
+// signedBody returns the request body and headers, with signature headers if a signing secret is set
+func (f *APIFunction) signedBody() (json.RawMessage, map[string]string, error) {
+	header := f.header()
+
+	body, err := json.Marshal(f.request)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if f.signingSecret != "" {
+		for key, value := range SignatureHeaders(f.signingSecret, body) {
+			header[key] = value
+		}
+	}
+
+	return body, header, nil
+}
+
+func copyStringMap(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+
+	copied := make(map[string]string, len(m))
+	for key, value := range m {
+		copied[key] = value
+	}
+
+	return copied
+}
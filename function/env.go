@@ -0,0 +1,39 @@
+package function
+
+import (
+	"os"
+	"time"
+
+	sdk "github.com/ucode-io/ucode_sdk"
+)
+
+// Environment variables read by ConfigFromEnv
+const (
+	EnvBaseURL        = "UCODE_BASE_URL"
+	EnvBaseAuthURL    = "UCODE_BASE_AUTH_URL"
+	EnvAppID          = "UCODE_APP_ID"
+	EnvProjectID      = "UCODE_PROJECT_ID"
+	EnvFunctionName   = "UCODE_FUNCTION_NAME"
+	EnvRequestTimeout = "UCODE_REQUEST_TIMEOUT"
+	EnvMQTTBroker     = "UCODE_MQTT_BROKER"
+	EnvMQTTUsername   = "UCODE_MQTT_USERNAME"
+	EnvMQTTPassword   = "UCODE_MQTT_PASSWORD"
+)
+
+// ConfigFromEnv creates the SDK config from UCODE_* environment variables.
+// UCODE_REQUEST_TIMEOUT is a duration like "30s".
+func ConfigFromEnv() *sdk.Config {
+	timeout, _ := time.ParseDuration(os.Getenv(EnvRequestTimeout))
+
+	return &sdk.Config{
+		BaseURL:        os.Getenv(EnvBaseURL),
+		BaseAuthUrl:    os.Getenv(EnvBaseAuthURL),
+		AppId:          os.Getenv(EnvAppID),
+		ProjectId:      os.Getenv(EnvProjectID),
+		FunctionName:   os.Getenv(EnvFunctionName),
+		RequestTimeout: timeout,
+		MQTTBroker:     os.Getenv(EnvMQTTBroker),
+		MQTTUsername:   os.Getenv(EnvMQTTUsername),
+		MQTTPassword:   os.Getenv(EnvMQTTPassword),
+	}
+}
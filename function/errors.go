@@ -0,0 +1,55 @@
+package function
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Error is a handler error with the status code and client message of the response
+type Error struct {
+	StatusCode int
+	Message    string
+	Err        error
+}
+
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return e.Message
+	}
+	return fmt.Sprintf("%s: %v", e.Message, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func (e *Error) errorMessage() string {
+	if e.Err == nil {
+		return ""
+	}
+	return e.Err.Error()
+}
+
+func NewError(statusCode int, message string, err error) *Error {
+	return &Error{StatusCode: statusCode, Message: message, Err: err}
+}
+
+func BadRequest(message string, err error) *Error {
+	return NewError(http.StatusBadRequest, message, err)
+}
+
+func Unauthorized(message string, err error) *Error {
+	return NewError(http.StatusUnauthorized, message, err)
+}
+
+func Forbidden(message string, err error) *Error {
+	return NewError(http.StatusForbidden, message, err)
+}
+
+func NotFound(message string, err error) *Error {
+	return NewError(http.StatusNotFound, message, err)
+}
+
+func Internal(message string, err error) *Error {
+	return NewError(http.StatusInternalServerError, message, err)
+}
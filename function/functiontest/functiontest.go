@@ -0,0 +1,227 @@
+// Package functiontest provides utilities for testing Ucode function handlers.
+//
+//	func TestHandle(t *testing.T) {
+//		fake := functiontest.NewFake(t)
+//		fake.Seed("order", map[string]any{"guid": "1", "status": "new"})
+//
+//		functiontest.Invoke(t, Handle(), functiontest.LoadFixture(t, "testdata/request.json")).
+//			AssertStatus(http.StatusOK).
+//			AssertData("status", "done").
+//			MatchGolden("handle")
+//	}
+//
+// Golden files live in testdata/NAME.golden, run tests with UCODE_UPDATE_GOLDEN=1
+// to rewrite them.
+package functiontest
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	sdk "github.com/ucode-io/ucode_sdk"
+	"github.com/ucode-io/ucode_sdk/function"
+	"github.com/ucode-io/ucode_sdk/ucodetest"
+)
+
+// EnvUpdateGolden makes MatchGolden rewrite golden files instead of comparing
+// them when set to a non-empty value
+const EnvUpdateGolden = "UCODE_UPDATE_GOLDEN"
+
+// NewFake starts an in-memory Ucode API for the test and points UCODE_*
+// environment variables to it, so handlers created afterwards with function.Handle use it
+func NewFake(t testing.TB) *ucodetest.Server {
+	t.Helper()
+
+	srv := ucodetest.NewServer()
+	t.Cleanup(srv.Close)
+
+	config := srv.Config()
+	t.Setenv(function.EnvBaseURL, config.BaseURL)
+	t.Setenv(function.EnvBaseAuthURL, config.BaseAuthUrl)
+	t.Setenv(function.EnvAppID, config.AppId)
+	t.Setenv(function.EnvProjectID, config.ProjectId)
+
+	return srv
+}
+
+// LoadFixture reads a request payload file like example/request.json
+func LoadFixture(t testing.TB, path string) []byte {
+	t.Helper()
+
+	body, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading fixture: %v", err)
+	}
+
+	if !json.Valid(body) {
+		t.Fatalf("fixture %s is not valid JSON", path)
+	}
+
+	return body
+}
+
+// Payload builds a request body with data as sdk.Request.Data
+func Payload(t testing.TB, data map[string]any) []byte {
+	t.Helper()
+
+	body, err := json.Marshal(sdk.Request{Data: data})
+	if err != nil {
+		t.Fatalf("marshalling payload: %v", err)
+	}
+
+	return body
+}
+
+// Invoke posts body to h the way the platform invokes functions
+func Invoke(t testing.TB, h http.Handler, body []byte) *Result {
+	t.Helper()
+
+	var (
+		rec     = httptest.NewRecorder()
+		request = httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	)
+	request.Header.Set("Content-Type", "application/json")
+
+	h.ServeHTTP(rec, request)
+
+	result := &Result{t: t, StatusCode: rec.Code, Body: rec.Body.Bytes()}
+	if err := json.Unmarshal(result.Body, &result.Response); err != nil {
+		t.Fatalf("response is not sdk.Response: %v\n%s", err, result.Body)
+	}
+
+	return result
+}
+
+// InvokeFunc invokes a function.HandlerFunc with ucode injected as its SDK,
+// e.g. ucodetest.Server.SDK() or a mock implementing sdk.UcodeApis
+func InvokeFunc(t testing.TB, fn function.HandlerFunc, ucode sdk.UcodeApis, body []byte) *Result {
+	t.Helper()
+
+	return Invoke(t, function.HandleWith(fn, function.Options{SDK: ucode}), body)
+}
+
+type Result struct {
+	t          testing.TB
+	StatusCode int
+	Body       []byte
+	Response   sdk.Response
+}
+
+func (r *Result) AssertStatus(statusCode int) *Result {
+	r.t.Helper()
+
+	if r.StatusCode != statusCode {
+		r.t.Errorf("status code = %d, want %d\n%s", r.StatusCode, statusCode, r.Body)
+	}
+
+	return r
+}
+
+// AssertResponseStatus checks sdk.Response.Status, "done" or "error"
+func (r *Result) AssertResponseStatus(status string) *Result {
+	r.t.Helper()
+
+	if r.Response.Status != status {
+		r.t.Errorf("response status = %q, want %q\n%s", r.Response.Status, status, r.Body)
+	}
+
+	return r
+}
+
+// AssertData checks a value of sdk.Response.Data. want is compared with the
+// JSON decoded value, so numbers should be float64.
+func (r *Result) AssertData(key string, want any) *Result {
+	r.t.Helper()
+
+	got, ok := r.Response.Data[key]
+	if !ok {
+		r.t.Errorf("response data has no %q\n%s", key, r.Body)
+		return r
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		r.t.Errorf("response data %q = %#v, want %#v", key, got, want)
+	}
+
+	return r
+}
+
+// DecodeData decodes sdk.Response.Data into v
+func (r *Result) DecodeData(v any) *Result {
+	r.t.Helper()
+
+	body, err := json.Marshal(r.Response.Data)
+	if err == nil {
+		err = json.Unmarshal(body, v)
+	}
+
+	if err != nil {
+		r.t.Fatalf("decoding response data: %v", err)
+	}
+
+	return r
+}
+
+// MatchGolden compares the response with testdata/NAME.golden. Keys listed in
+// ignore are removed at any depth first, use it for generated ids and times.
+func (r *Result) MatchGolden(name string, ignore ...string) *Result {
+	r.t.Helper()
+
+	var response any
+	if err := json.Unmarshal(r.Body, &response); err != nil {
+		r.t.Fatalf("decoding response: %v", err)
+	}
+
+	removeKeys(response, ignore)
+
+	got, err := json.MarshalIndent(map[string]any{"status_code": r.StatusCode, "body": response}, "", "  ")
+	if err != nil {
+		r.t.Fatalf("marshalling response: %v", err)
+	}
+	got = append(got, '\n')
+
+	path := filepath.Join("testdata", name+".golden")
+
+	if os.Getenv(EnvUpdateGolden) != "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			r.t.Fatalf("creating testdata: %v", err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			r.t.Fatalf("writing golden file: %v", err)
+		}
+		return r
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		r.t.Fatalf("reading golden file (run with %s=1 to create it): %v", EnvUpdateGolden, err)
+	}
+
+	if !bytes.Equal(got, want) {
+		r.t.Errorf("response doesn't match %s (run with %s=1 to accept it)\ngot:\n%s\nwant:\n%s", path, EnvUpdateGolden, got, want)
+	}
+
+	return r
+}
+
+func removeKeys(value any, keys []string) {
+	switch v := value.(type) {
+	case map[string]any:
+		for _, key := range keys {
+			delete(v, key)
+		}
+		for _, elem := range v {
+			removeKeys(elem, keys)
+		}
+	case []any:
+		for _, elem := range v {
+			removeKeys(elem, keys)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package functiontest
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/spf13/cast"
+	sdk "github.com/ucode-io/ucode_sdk"
+	example "github.com/ucode-io/ucode_sdk/example"
+	"github.com/ucode-io/ucode_sdk/function"
+)
+
+func TestExampleHandler(t *testing.T) {
+	fake := NewFake(t)
+	fake.Seed("order", map[string]any{"guid": "1", "status": "new"}, map[string]any{"guid": "2", "status": "paid"})
+	fake.Seed("order_product",
+		map[string]any{"guid": "1", "quantity": 5},
+		map[string]any{"guid": "2", "quantity": 2},
+		map[string]any{"guid": "3", "quantity": 4},
+	)
+
+	Invoke(t, example.Handle(), LoadFixture(t, "../../example/request.json")).
+		AssertStatus(http.StatusOK).
+		AssertResponseStatus("done").
+		AssertData("new_orders", float64(1)).
+		AssertData("large_order_products", float64(2)).
+		MatchGolden("example")
+}
+
+func TestInvokeFunc(t *testing.T) {
+	fake := NewFake(t)
+	fake.Seed("houses", map[string]any{"guid": "h1", "name": "house_1", "price": 15000})
+
+	getHouse := func(ctx context.Context, req *sdk.Request, ucode sdk.UcodeApis) (any, error) {
+		house, _, err := ucode.Items("houses").GetSingle(cast.ToString(req.Data["guid"])).Exec()
+		if err != nil {
+			return nil, function.BadRequest("Error on getting house", err)
+		}
+		if house.Data.Data.Response == nil {
+			return nil, function.NotFound("House not found", nil)
+		}
+		return house.Data.Data.Response, nil
+	}
+
+	var house struct {
+		Name  string `json:"name"`
+		Price int    `json:"price"`
+	}
+
+	InvokeFunc(t, getHouse, fake.SDK(), Payload(t, map[string]any{"guid": "h1"})).
+		AssertStatus(http.StatusOK).
+		AssertData("name", "house_1").
+		DecodeData(&house)
+
+	if house.Price != 15000 {
+		t.Errorf("price = %d, want 15000", house.Price)
+	}
+
+	InvokeFunc(t, getHouse, fake.SDK(), Payload(t, map[string]any{"guid": "missing"})).
+		AssertStatus(http.StatusNotFound).
+		AssertResponseStatus("error").
+		AssertData("message", "House not found")
+}
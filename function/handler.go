@@ -0,0 +1,230 @@
+// Package function builds http handlers for Ucode FaaS functions.
+//
+// It reads and decodes the request body, creates the SDK, turns returned
+// values and errors into sdk.Response bodies and recovers panics, so a
+// function only contains its business logic. Details of 5xx errors are
+// logged, not returned to the caller:
+//
+//	func Handle() http.HandlerFunc {
+//		return function.Handle(func(ctx context.Context, req *sdk.Request, ucode sdk.UcodeApis) (any, error) {
+//			order, _, err := ucode.Items("order").GetSingle(cast.ToString(req.Data["guid"])).Exec()
+//			if err != nil {
+//				return nil, function.BadRequest("Error on getting order", err)
+//			}
+//			return order.Data.Data.Response, nil
+//		})
+//	}
+package function
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+
+	sdk "github.com/ucode-io/ucode_sdk"
+)
+
+// DefaultMaxBodyBytes is the request body limit used when Options.MaxBodyBytes is zero
+const DefaultMaxBodyBytes = 10 << 20
+
+// HandlerFunc is the business logic of a function. The returned value becomes
+// the data of a "done" response, the returned error an "error" response.
+type HandlerFunc func(ctx context.Context, req *sdk.Request, ucode sdk.UcodeApis) (any, error)
+
+type Options struct {
+	// Config the SDK is created with when the handler is built, defaults to ConfigFromEnv()
+	Config *sdk.Config
+	// SDK is passed to the handler instead of creating one from Config
+	SDK sdk.UcodeApis
+	// MaxBodyBytes limits the request body, default DefaultMaxBodyBytes
+	MaxBodyBytes int64
+	// Verifier rejects requests without a valid signature with 401
+	Verifier *sdk.SignatureVerifier
+	// Logger receives the details of 5xx errors and recovered panics, callers
+	// only get the message of such errors. Defaults to slog.Default()
+	Logger *slog.Logger
+}
+
+// Handle wraps h into an http.HandlerFunc with default options
+func Handle(h HandlerFunc) http.HandlerFunc {
+	return HandleWith(h, Options{})
+}
+
+func HandleWith(h HandlerFunc, opts Options) http.HandlerFunc {
+	if opts.MaxBodyBytes <= 0 {
+		opts.MaxBodyBytes = DefaultMaxBodyBytes
+	}
+
+	if opts.Logger == nil {
+		opts.Logger = slog.Default()
+	}
+
+	// the SDK is shared by all requests, its MQTT connection and RPC inbox
+	// are opened once instead of on every call
+	ucode := opts.SDK
+	if ucode == nil {
+		config := opts.Config
+		if config == nil {
+			config = ConfigFromEnv()
+		}
+		ucode = sdk.New(config)
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeError := func(w http.ResponseWriter, err error) {
+			writeErrorResponse(r.Context(), w, opts.Logger, err)
+		}
+
+		defer func() {
+			if rec := recover(); rec != nil {
+				writeError(w, &Error{
+					StatusCode: http.StatusInternalServerError,
+					Message:    "Internal server error",
+					Err:        fmt.Errorf("panic: %v\n%s", rec, debug.Stack()),
+				})
+			}
+		}()
+
+		var request sdk.Request
+
+		requestByte, err := io.ReadAll(http.MaxBytesReader(w, r.Body, opts.MaxBodyBytes))
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if !errors.As(err, &maxBytesErr) {
+				err = BadRequest("Error on getting request body", err)
+			}
+			writeError(w, err)
+			return
+		}
+
+		if opts.Verifier != nil {
+			if err := opts.Verifier.Verify(r.Header, requestByte); err != nil {
+				writeError(w, Unauthorized("Invalid request signature", err))
+				return
+			}
+		}
+
+		if len(requestByte) > 0 {
+			err = json.Unmarshal(requestByte, &request)
+			if err != nil {
+				writeError(w, BadRequest("Error on unmarshal request", err))
+				return
+			}
+		}
+
+		if request.Data == nil {
+			request.Data = map[string]any{}
+		}
+
+		result, err := h(r.Context(), &request, ucode)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		response, err := toResponse(result)
+		if err != nil {
+			writeError(w, &Error{StatusCode: http.StatusInternalServerError, Message: "Error marshalling response", Err: err})
+			return
+		}
+
+		writeResponse(w, response, http.StatusOK)
+	}
+}
+
+// toResponse wraps a handler result into sdk.Response. Maps and structs become
+// the response data, other values are stored under the "result" key.
+func toResponse(result any) (sdk.Response, error) {
+	switch v := result.(type) {
+	case nil:
+		return sdk.Response{Status: "done", Data: map[string]any{}}, nil
+	case sdk.Response:
+		if v.Status == "" {
+			v.Status = "done"
+		}
+		return v, nil
+	case *sdk.Response:
+		return toResponse(*v)
+	case map[string]any:
+		return sdk.Response{Status: "done", Data: v}, nil
+	}
+
+	body, err := json.Marshal(result)
+	if err != nil {
+		return sdk.Response{}, err
+	}
+
+	var data map[string]any
+	if err := json.Unmarshal(body, &data); err != nil {
+		var value any
+		if err := json.Unmarshal(body, &value); err != nil {
+			return sdk.Response{}, err
+		}
+		data = map[string]any{"result": value}
+	}
+
+	return sdk.Response{Status: "done", Data: data}, nil
+}
+
+// writeErrorResponse writes err as an "error" response. The error text of 5xx
+// errors can contain internals like panic values, it is logged instead of sent.
+func writeErrorResponse(ctx context.Context, w http.ResponseWriter, logger *slog.Logger, err error) {
+	var (
+		fnErr = toError(err)
+		data  = map[string]any{"message": fnErr.Message}
+	)
+
+	if fnErr.StatusCode >= http.StatusInternalServerError {
+		logger.ErrorContext(ctx, "function failed", "status", fnErr.StatusCode, "message", fnErr.Message, "error", fnErr.errorMessage())
+	} else {
+		data["error"] = fnErr.errorMessage()
+	}
+
+	writeResponse(w, sdk.Response{Status: "error", Data: data}, fnErr.StatusCode)
+}
+
+func writeResponse(w http.ResponseWriter, body any, statusCode int) {
+	w.Header().Set("Content-Type", "application/json")
+
+	bodyByte, err := json.Marshal(body)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"status":"error","data":{"message":"Error marshalling response"}}`))
+		return
+	}
+
+	w.WriteHeader(statusCode)
+	w.Write(bodyByte)
+}
+
+// toError converts any error returned by a handler to *Error choosing the
+// status code from well known errors
+func toError(err error) *Error {
+	var (
+		fnErr       *Error
+		maxBytesErr *http.MaxBytesError
+		functionErr *sdk.FunctionError
+	)
+
+	switch {
+	case errors.As(err, &fnErr):
+		return fnErr
+	case errors.As(err, &maxBytesErr):
+		return &Error{StatusCode: http.StatusRequestEntityTooLarge, Message: "Request body is too large", Err: err}
+	case errors.As(err, &functionErr):
+		return &Error{StatusCode: http.StatusBadGateway, Message: "Error on invoking function", Err: err}
+	case errors.Is(err, sdk.ErrNoTriggerHandler), errors.Is(err, sdk.ErrInvalidTriggerEvent):
+		return &Error{StatusCode: http.StatusBadRequest, Message: "Unsupported trigger event", Err: err}
+	case errors.Is(err, context.DeadlineExceeded):
+		return &Error{StatusCode: http.StatusGatewayTimeout, Message: "Request timed out", Err: err}
+	case errors.Is(err, context.Canceled):
+		return &Error{StatusCode: http.StatusServiceUnavailable, Message: "Request canceled", Err: err}
+	}
+
+	return &Error{StatusCode: http.StatusInternalServerError, Message: "Internal server error", Err: err}
+}
@@ -0,0 +1,121 @@
+package function
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	sdk "github.com/ucode-io/ucode_sdk"
+)
+
+func serve(t *testing.T, h http.Handler, body string) (int, sdk.Response) {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+
+	var response sdk.Response
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+
+	return rec.Code, response
+}
+
+func TestHandle(t *testing.T) {
+	var (
+		opts = Options{SDK: sdk.New(&sdk.Config{}), MaxBodyBytes: 64}
+		body = `{"data":{"name":"house_1"}}`
+	)
+
+	t.Run("result", func(t *testing.T) {
+		h := HandleWith(func(ctx context.Context, req *sdk.Request, ucode sdk.UcodeApis) (any, error) {
+			return struct {
+				Name string `json:"name"`
+			}{Name: req.Data["name"].(string)}, nil
+		}, opts)
+
+		code, response := serve(t, h, body)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "done", response.Status)
+		assert.Equal(t, "house_1", response.Data["name"])
+	})
+
+	t.Run("error", func(t *testing.T) {
+		h := HandleWith(func(ctx context.Context, req *sdk.Request, ucode sdk.UcodeApis) (any, error) {
+			return nil, NotFound("House not found", errors.New("no rows"))
+		}, opts)
+
+		code, response := serve(t, h, body)
+		assert.Equal(t, http.StatusNotFound, code)
+		assert.Equal(t, "error", response.Status)
+		assert.Equal(t, "House not found", response.Data["message"])
+		assert.Equal(t, "no rows", response.Data["error"])
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		var (
+			logs bytes.Buffer
+			opts = opts
+		)
+		opts.Logger = slog.New(slog.NewTextHandler(&logs, nil))
+
+		h := HandleWith(func(ctx context.Context, req *sdk.Request, ucode sdk.UcodeApis) (any, error) {
+			panic("boom")
+		}, opts)
+
+		code, response := serve(t, h, body)
+		assert.Equal(t, http.StatusInternalServerError, code)
+		assert.Equal(t, "Internal server error", response.Data["message"])
+		assert.NotContains(t, response.Data, "error")
+		assert.Contains(t, logs.String(), "panic: boom")
+	})
+
+	t.Run("internal error", func(t *testing.T) {
+		var (
+			logs bytes.Buffer
+			opts = opts
+		)
+		opts.Logger = slog.New(slog.NewTextHandler(&logs, nil))
+
+		h := HandleWith(func(ctx context.Context, req *sdk.Request, ucode sdk.UcodeApis) (any, error) {
+			return nil, errors.New("dial tcp 10.0.0.5:5432: connection refused")
+		}, opts)
+
+		code, response := serve(t, h, body)
+		assert.Equal(t, http.StatusInternalServerError, code)
+		assert.Equal(t, "Internal server error", response.Data["message"])
+		assert.NotContains(t, response.Data, "error")
+		assert.Contains(t, logs.String(), "10.0.0.5:5432")
+	})
+
+	t.Run("body limit", func(t *testing.T) {
+		h := HandleWith(func(ctx context.Context, req *sdk.Request, ucode sdk.UcodeApis) (any, error) {
+			return nil, nil
+		}, opts)
+
+		code, _ := serve(t, h, `{"data":{"name":"`+strings.Repeat("a", 100)+`"}}`)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, code)
+
+		code, _ = serve(t, h, `{"data":`)
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+
+	t.Run("shared sdk", func(t *testing.T) {
+		var seen []sdk.UcodeApis
+		h := HandleWith(func(ctx context.Context, req *sdk.Request, ucode sdk.UcodeApis) (any, error) {
+			seen = append(seen, ucode)
+			return nil, nil
+		}, Options{Config: &sdk.Config{}})
+
+		serve(t, h, body)
+		serve(t, h, body)
+		assert.Len(t, seen, 2)
+		assert.Same(t, seen[0], seen[1])
+	})
+}
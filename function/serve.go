@@ -0,0 +1,148 @@
+package function
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+type ServeOptions struct {
+	// Addr to listen on, defaults to localhost:$PORT or localhost:8080
+	Addr string
+	// Payload is a JSON file posted to the handler once it listens, e.g. example/request.json
+	Payload string
+	// Logger for served requests, defaults to log.Default()
+	Logger *log.Logger
+}
+
+// ServeMain runs Serve with options parsed from command line flags and exits on error.
+// It is the main function of programs generated by "ucode-fn serve".
+func ServeMain(h http.Handler) {
+	var opts ServeOptions
+
+	flag.StringVar(&opts.Addr, "addr", "", "address to listen on")
+	flag.StringVar(&opts.Payload, "payload", "", "JSON request file posted to the function after start")
+	flag.Parse()
+
+	if err := Serve(h, opts); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// Serve hosts h locally the way the platform invokes functions, until SIGINT or SIGTERM.
+// The SDK of handlers created with Handle talks to UCODE_BASE_URL, "ucode-fn serve -backend"
+// points it to a fake, recording or replaying API.
+func Serve(h http.Handler, opts ServeOptions) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	return listenAndServe(ctx, h, opts, nil)
+}
+
+// listenAndServe hosts h until ctx is done, the url it listens on is passed to ready
+func listenAndServe(ctx context.Context, h http.Handler, opts ServeOptions, ready func(url string)) error {
+	if opts.Logger == nil {
+		opts.Logger = log.Default()
+	}
+
+	if opts.Addr == "" {
+		opts.Addr = "localhost:8080"
+		if port := os.Getenv("PORT"); port != "" {
+			opts.Addr = "localhost:" + port
+		}
+	}
+
+	listener, err := net.Listen("tcp", opts.Addr)
+	if err != nil {
+		return err
+	}
+
+	server := &http.Server{Handler: logRequests(h, opts.Logger)}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.Serve(listener)
+	}()
+
+	url := "http://" + listener.Addr().String()
+	opts.Logger.Printf("serving function on %s", url)
+
+	if opts.Payload != "" {
+		if err := postPayload(url, opts.Payload, opts.Logger); err != nil {
+			opts.Logger.Printf("posting payload %s: %v", opts.Payload, err)
+		}
+	}
+
+	if ready != nil {
+		ready(url)
+	}
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+func postPayload(url, payload string, logger *log.Logger) error {
+	body, err := os.ReadFile(payload)
+	if err != nil {
+		return err
+	}
+
+	resp, err := http.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	logger.Printf("payload %s -> %d %s", payload, resp.StatusCode, respBody)
+
+	return nil
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func logRequests(h http.Handler, logger *log.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var (
+			start = time.Now()
+			rec   = &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		)
+
+		h.ServeHTTP(rec, r)
+
+		logger.Printf("%s %s %d %s", r.Method, r.URL.Path, rec.status, time.Since(start).Round(time.Millisecond))
+	})
+}
@@ -0,0 +1,92 @@
+package function
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	sdk "github.com/ucode-io/ucode_sdk"
+)
+
+// syncBuffer is a bytes.Buffer safe to share between the server and the test
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestListenAndServe(t *testing.T) {
+	var (
+		logs    syncBuffer
+		payload = filepath.Join(t.TempDir(), "request.json")
+		urls    = make(chan string, 1)
+		done    = make(chan error, 1)
+	)
+	assert.NoError(t, os.WriteFile(payload, []byte(`{"data":{"name":"house_1"}}`), 0o644))
+
+	h := HandleWith(func(ctx context.Context, req *sdk.Request, ucode sdk.UcodeApis) (any, error) {
+		return req.Data, nil
+	}, Options{SDK: sdk.New(&sdk.Config{})})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		done <- listenAndServe(ctx, h, ServeOptions{
+			Addr:    "127.0.0.1:0",
+			Payload: payload,
+			Logger:  log.New(&logs, "", 0),
+		}, func(url string) { urls <- url })
+	}()
+
+	url := <-urls
+	assert.Contains(t, logs.String(), "serving function on "+url)
+	assert.Contains(t, logs.String(), `-> 200 {"status":"done","error":"","data":{"name":"house_1"}}`)
+
+	resp, err := http.Post(url+"/", "application/json", strings.NewReader(`{"data":`))
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Contains(t, logs.String(), "POST / 400")
+
+	cancel()
+	assert.NoError(t, <-done)
+
+	_, err = http.Post(url+"/", "application/json", nil)
+	assert.Error(t, err)
+}
+
+func TestListenAndServeAddrInUse(t *testing.T) {
+	var (
+		urls = make(chan string, 1)
+		ctx  = context.Background()
+		h    = http.NotFoundHandler()
+		opts = ServeOptions{Addr: "127.0.0.1:0", Logger: log.New(&syncBuffer{}, "", 0)}
+	)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	go listenAndServe(ctx, h, opts, func(url string) { urls <- url })
+
+	opts.Addr = strings.TrimPrefix(<-urls, "http://")
+	assert.Error(t, listenAndServe(ctx, h, opts, nil))
+}
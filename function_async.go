@@ -0,0 +1,173 @@
+package ucodesdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	nurl "net/url"
+	"time"
+)
+
+const (
+	FunctionJobPending  = "pending"
+	FunctionJobRunning  = "running"
+	FunctionJobDone     = "done"
+	FunctionJobError    = "error"
+	FunctionJobCanceled = "canceled"
+)
+
+var ErrFunctionJobCanceled = errors.New("function job canceled")
+
+func (f *APIFunction) InvokeAsync(data map[string]any) (*FunctionJob, error) {
+	job, _, err := f.Invoke(data).ExecAsync()
+	return job, err
+}
+
+/*
+ExecAsync starts the function on the server and returns without waiting for its result.
+Use the returned job to poll its status.
+
+Jobs use POST /v1/invoke_function/{path}/async to start, GET /v1/invoke_function/jobs/{id}
+for the status and DELETE on the same path to cancel. These endpoints aren't part of the
+documented Ucode API, the backend must provide them; ucodetest doesn't.
+*/
+func (f *APIFunction) ExecAsync() (*FunctionJob, Response, error) {
+	var (
+		response    = Response{Status: "done"}
+		startObject FunctionJobResponse
+		url         = f.url("/async")
+		ctx         = orBackground(f.ctx)
+	)
+
+	if f.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, f.timeout)
+		defer cancel()
+	}
+
+	body, header, err := f.signedBody()
+	if err != nil {
+		response.Data = map[string]any{"message": "Error while marshalling invoke function request", "error": err.Error()}
+		response.Status = "error"
+		return nil, response, err
+	}
+
+	startResponseInByte, err := doCheckedRequest(ctx, f.config, requestInfo{operation: "function.invoke_async"}, url, http.MethodPost, body, header)
+	if err != nil {
+		response.Data = map[string]any{"description": string(startResponseInByte), "message": "Can't send request", "error": err.Error()}
+		response.Status = "error"
+		return nil, response, err
+	}
+
+	err = json.Unmarshal(startResponseInByte, &startObject)
+	if err != nil {
+		response.Data = map[string]any{"description": string(startResponseInByte), "message": "Error while unmarshalling function job", "error": err.Error()}
+		response.Status = "error"
+		return nil, response, err
+	}
+
+	if startObject.Data.JobID == "" {
+		err = fmt.Errorf("function %s didn't return job id", f.path)
+		response.Data = map[string]any{"description": string(startResponseInByte), "message": "Error while starting function job", "error": err.Error()}
+		response.Status = "error"
+		return nil, response, err
+	}
+
+	return &FunctionJob{
+		config:      f.config,
+		path:        f.path,
+		id:          startObject.Data.JobID,
+		header:      f.header(),
+		minInterval: 500 * time.Millisecond,
+		maxInterval: 10 * time.Second,
+	}, response, nil
+}
+
+func (j *FunctionJob) ID() string {
+	return j.id
+}
+
+// Backoff sets the first and the longest interval between status polls of Wait, default 500ms and 10s
+func (j *FunctionJob) Backoff(first, longest time.Duration) *FunctionJob {
+	if first > 0 {
+		j.minInterval = first
+	}
+	if longest >= j.minInterval {
+		j.maxInterval = longest
+	}
+	return j
+}
+
+func (j *FunctionJob) Status(ctx context.Context) (FunctionJobStatus, error) {
+	return j.status(ctx)
+}
+
+/*
+Wait polls the job status until the job finishes or ctx is done.
+
+If the function failed the error is a *FunctionError, a canceled job returns ErrFunctionJobCanceled.
+A poll answered with a non-2xx status fails with a *StatusError, an unknown job status with an error.
+*/
+func (j *FunctionJob) Wait(ctx context.Context) (FunctionResponse, error) {
+	interval := j.minInterval
+
+	for {
+		status, err := j.status(ctx)
+		if err != nil {
+			return FunctionResponse{}, err
+		}
+
+		switch status.Status {
+		case FunctionJobDone:
+			return FunctionResponse{Status: status.Status, Data: status.Result}, nil
+		case FunctionJobError:
+			return FunctionResponse{}, &FunctionError{Path: j.path, Status: status.Status, Description: status.Error}
+		case FunctionJobCanceled:
+			return FunctionResponse{}, ErrFunctionJobCanceled
+		case FunctionJobPending, FunctionJobRunning:
+		default:
+			return FunctionResponse{}, fmt.Errorf("function job %s has unknown status %q", j.id, status.Status)
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return FunctionResponse{}, ctx.Err()
+		case <-timer.C:
+		}
+
+		interval *= 2
+		if interval > j.maxInterval {
+			interval = j.maxInterval
+		}
+	}
+}
+
+func (j *FunctionJob) Cancel(ctx context.Context) error {
+	url := fmt.Sprintf("%s/v1/invoke_function/jobs/%s", j.config.BaseURL, nurl.PathEscape(j.id))
+
+	_, err := doCheckedRequest(orBackground(ctx), j.config, requestInfo{operation: "function.job_cancel"}, url, http.MethodDelete, nil, j.header)
+	return err
+}
+
+func (j *FunctionJob) status(ctx context.Context) (FunctionJobStatus, error) {
+	var (
+		statusObject FunctionJobResponse
+		url          = fmt.Sprintf("%s/v1/invoke_function/jobs/%s", j.config.BaseURL, nurl.PathEscape(j.id))
+	)
+
+	statusResponseInByte, err := doCheckedRequest(orBackground(ctx), j.config, requestInfo{operation: "function.job_status"}, url, http.MethodGet, nil, j.header)
+	if err != nil {
+		return FunctionJobStatus{}, err
+	}
+
+	err = json.Unmarshal(statusResponseInByte, &statusObject)
+	if err != nil {
+		return FunctionJobStatus{}, err
+	}
+
+	return statusObject.Data, nil
+}
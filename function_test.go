@@ -0,0 +1,236 @@
+package ucodesdk
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFunctionInvoke(t *testing.T) {
+	var (
+		gotPath    string
+		gotQuery   string
+		gotHeader  http.Header
+		gotRequest Request
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotQuery = r.URL.RawQuery
+		gotHeader = r.Header
+		json.NewDecoder(r.Body).Decode(&gotRequest)
+
+		if r.URL.Query().Get("sleep") != "" {
+			time.Sleep(200 * time.Millisecond)
+		}
+
+		json.NewEncoder(w).Encode(FunctionResponse{Status: "done", Data: map[string]any{"ok": true}})
+	}))
+	defer server.Close()
+
+	api := New(&Config{BaseURL: server.URL, AppId: "app-id"})
+
+	resp, response, err := api.Function("calculate-price").
+		Invoke(map[string]any{"order_id": "1"}).
+		Headers(map[string]string{"X-Request-Id": "req-1"}).
+		QueryParams(map[string]string{"lang": "en"}).
+		IsCached(true).
+		Exec()
+	assert.NoError(t, err)
+	assert.Equal(t, "done", response.Status)
+	assert.Equal(t, "done", resp.Status)
+	assert.Equal(t, "/v1/invoke_function/calculate-price", gotPath)
+	assert.Equal(t, "lang=en", gotQuery)
+	assert.Equal(t, "req-1", gotHeader.Get("X-Request-Id"))
+	assert.Equal(t, "app-id", gotHeader.Get("X-API-KEY"))
+	assert.True(t, gotRequest.IsCached)
+	assert.Equal(t, "1", gotRequest.Data["order_id"])
+
+	_, response, err = api.Function("slow").
+		Invoke(nil).
+		QueryParams(map[string]string{"sleep": "1"}).
+		Timeout(50 * time.Millisecond).
+		Exec()
+	assert.Error(t, err)
+	assert.Equal(t, "error", response.Status)
+}
+
+func TestInvokeTyped(t *testing.T) {
+	type priceRequest struct {
+		OrderID string `json:"order_id"`
+	}
+
+	type priceResponse struct {
+		Total float64 `json:"total"`
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request Request
+		json.NewDecoder(r.Body).Decode(&request)
+
+		if request.Data["order_id"] == "missing" {
+			json.NewEncoder(w).Encode(FunctionResponse{Status: "error", CustomMessage: "order not found"})
+			return
+		}
+
+		json.NewEncoder(w).Encode(FunctionResponse{Status: "done", Data: map[string]any{"total": 42.5}})
+	}))
+	defer server.Close()
+
+	api := New(&Config{BaseURL: server.URL})
+
+	price, err := InvokeTyped[priceRequest, priceResponse](api, "price", priceRequest{OrderID: "1"})
+	assert.NoError(t, err)
+	assert.Equal(t, 42.5, price.Total)
+
+	_, err = InvokeTyped[priceRequest, priceResponse](api, "price", priceRequest{OrderID: "missing"})
+
+	var functionErr *FunctionError
+	assert.ErrorAs(t, err, &functionErr)
+	assert.Equal(t, "order not found", functionErr.CustomMessage)
+}
+
+func TestFunctionInvokeAsync(t *testing.T) {
+	var polls int
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var resp FunctionJobResponse
+
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/invoke_function/report/async":
+			resp.Data = FunctionJobStatus{JobID: "job-1", Status: FunctionJobPending}
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/invoke_function/jobs/job-1":
+			polls++
+			resp.Data = FunctionJobStatus{JobID: "job-1", Status: FunctionJobRunning}
+			if polls == 3 {
+				resp.Data = FunctionJobStatus{JobID: "job-1", Status: FunctionJobDone, Result: "ready"}
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	api := New(&Config{BaseURL: server.URL})
+
+	job, err := api.Function("report").InvokeAsync(map[string]any{"month": 1})
+	assert.NoError(t, err)
+	assert.Equal(t, "job-1", job.ID())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	result, err := job.Backoff(time.Millisecond, 5*time.Millisecond).Wait(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "ready", result.Data)
+	assert.Equal(t, 3, polls)
+}
+
+func TestFunctionJobWaitFailures(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var resp FunctionJobResponse
+
+		switch r.URL.Path {
+		case "/v1/invoke_function/report/async":
+			resp.Data = FunctionJobStatus{JobID: r.URL.Query().Get("job"), Status: FunctionJobPending}
+		case "/v1/invoke_function/jobs/lost":
+			w.WriteHeader(http.StatusNotFound)
+			return
+		case "/v1/invoke_function/jobs/odd":
+			resp.Data = FunctionJobStatus{JobID: "odd", Status: "paused"}
+		}
+
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	api := New(&Config{BaseURL: server.URL})
+
+	// neither a missing job nor an unknown status polls forever
+	job, _, err := api.Function("report").Invoke(nil).QueryParams(map[string]string{"job": "lost"}).ExecAsync()
+	assert.NoError(t, err)
+
+	_, err = job.Backoff(time.Millisecond, time.Millisecond).Wait(context.Background())
+	var statusErr *StatusError
+	assert.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, http.StatusNotFound, statusErr.StatusCode)
+	assert.ErrorAs(t, job.Cancel(context.Background()), &statusErr)
+
+	job, _, err = api.Function("report").Invoke(nil).QueryParams(map[string]string{"job": "odd"}).ExecAsync()
+	assert.NoError(t, err)
+
+	_, err = job.Backoff(time.Millisecond, time.Millisecond).Wait(context.Background())
+	assert.ErrorContains(t, err, `unknown status "paused"`)
+}
+
+func TestFunctionEscapedPaths(t *testing.T) {
+	var paths []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.EscapedPath())
+
+		var resp FunctionJobResponse
+		resp.Data = FunctionJobStatus{JobID: "a/b?c", Status: FunctionJobPending}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	api := New(&Config{BaseURL: server.URL})
+
+	job, err := api.Function("reports/monthly").InvokeAsync(nil)
+	assert.NoError(t, err)
+	assert.NoError(t, job.Cancel(context.Background()))
+
+	_, err = api.Files().Delete("media/x y").Exec()
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"/v1/invoke_function/reports%2Fmonthly/async",
+		"/v1/invoke_function/jobs/a%2Fb%3Fc",
+		"/v1/files/media%2Fx%20y",
+	}, paths)
+}
+
+func TestFanOut(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request Request
+		json.NewDecoder(r.Body).Decode(&request)
+
+		if request.Data["n"] == float64(2) {
+			json.NewEncoder(w).Encode(FunctionResponse{Status: "error", Description: "bad n"})
+			return
+		}
+
+		json.NewEncoder(w).Encode(FunctionResponse{Status: "done", Data: request.Data["n"]})
+	}))
+	defer server.Close()
+
+	var (
+		api   = New(&Config{BaseURL: server.URL})
+		calls = FunctionCalls("square", map[string]any{"n": 1}, map[string]any{"n": 2}, map[string]any{"n": 3})
+	)
+
+	results, response, err := api.FanOut(calls...).Concurrency(2).Exec()
+	assert.Error(t, err)
+	assert.Equal(t, "error", response.Status)
+	assert.Len(t, results, 3)
+	assert.Equal(t, float64(1), results[0].Response.Data)
+	assert.Equal(t, float64(3), results[2].Response.Data)
+
+	var functionErr *FunctionError
+	assert.ErrorAs(t, results[1].Err, &functionErr)
+	assert.Equal(t, "bad n", functionErr.Description)
+
+	results, _, err = api.FanOut(calls...).Concurrency(1).FailFast(true).Exec()
+	assert.ErrorAs(t, err, &functionErr)
+	assert.NoError(t, results[0].Err)
+	assert.ErrorIs(t, results[2].Err, context.Canceled)
+}
@@ -0,0 +1,106 @@
+package ucodesdk
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// FunctionError is returned by InvokeTyped when the function responds with status "error"
+type FunctionError struct {
+	Path          string
+	Status        string
+	Description   string
+	CustomMessage any
+}
+
+func (e *FunctionError) Error() string {
+	message := e.Description
+	if e.CustomMessage != nil && e.CustomMessage != "" {
+		message = fmt.Sprint(e.CustomMessage)
+	}
+
+	return fmt.Sprintf("function %s returned %s: %s", e.Path, e.Status, message)
+}
+
+// Err returns a *FunctionError when the function at path responded with status "error", otherwise nil
+func (r FunctionResponse) Err(path string) error {
+	if r.Status != "error" {
+		return nil
+	}
+
+	return &FunctionError{
+		Path:          path,
+		Status:        r.Status,
+		Description:   r.Description,
+		CustomMessage: r.CustomMessage,
+	}
+}
+
+/*
+InvokeTyped invokes the function at path with req as Request.Data and decodes
+FunctionResponse.Data into Resp.
+
+	type PriceRequest struct {
+		OrderID string `json:"order_id"`
+	}
+
+	type PriceResponse struct {
+		Total float64 `json:"total"`
+	}
+
+	price, err := ucodesdk.InvokeTyped[PriceRequest, PriceResponse](sdk, "calculate-price", PriceRequest{OrderID: "1"})
+
+Req must marshal to a JSON object. If the function responds with status "error"
+the returned error is a *FunctionError.
+*/
+func InvokeTyped[Req, Resp any](sdk UcodeApis, path string, req Req) (Resp, error) {
+	var resp Resp
+
+	data, err := toMap(req)
+	if err != nil {
+		return resp, fmt.Errorf("marshalling function request: %w", err)
+	}
+
+	result, _, err := sdk.Function(path).Invoke(data).Exec()
+	if err != nil {
+		return resp, err
+	}
+
+	if err := result.Err(path); err != nil {
+		return resp, err
+	}
+
+	if result.Data == nil {
+		return resp, nil
+	}
+
+	body, err := json.Marshal(result.Data)
+	if err != nil {
+		return resp, fmt.Errorf("decoding function response: %w", err)
+	}
+
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return resp, fmt.Errorf("decoding function response: %w", err)
+	}
+
+	return resp, nil
+}
+
+// toMap converts a struct to map[string]any through its JSON representation
+func toMap(v any) (map[string]any, error) {
+	if m, ok := v.(map[string]any); ok {
+		return m, nil
+	}
+
+	body, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var m map[string]any
+	if err := json.Unmarshal(body, &m); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
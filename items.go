@@ -1,7 +1,9 @@
 package ucodesdk
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	nurl "net/url"
@@ -11,6 +13,7 @@ func (u *object) Items(collection string) ItemsI {
 	return &APIItem{
 		collection: collection,
 		config:     u.config,
+		mqtt:       u.mqtt,
 	}
 }
 
@@ -69,6 +72,39 @@ type ItemsI interface {
 		Works for [Mongo, Postgres]
 	*/
 	GetSingle(id string) *GetSingleItem
+	/*
+		AttachFile is a function that uploads a file and stores its link in a file or photo field of an object.
+
+		User DisableFaas(false) method to enable faas: default true
+
+		sdk.Items("table_name").
+			AttachFile("guid", "field_name", "file_path").
+			FolderName("Media"). //default Media
+			Exec()
+
+		If updating the object fails or the server rejects it, the uploaded file is deleted again.
+
+		Works for [Mongo, Postgres]
+	*/
+	AttachFile(guid, field, source string) *AttachFile
+	/*
+		Watch is a function that subscribes to create, update and delete events of the collection over MQTT.
+
+		watcher, err := sdk.Items("table_name").
+			Watch(ctx, map[string]any{"status": []string{"new", "paid"}}).
+			Events(ucodesdk.ItemCreated, ucodesdk.ItemUpdated). //default all
+			Buffer(100). //default 64
+			Exec()
+
+		for event := range watcher.Events() {
+			fmt.Println(event.Type, event.ObjectID, event.Data)
+		}
+
+		Use OnEvent(func(ucodesdk.ItemEvent)) to get events in a callback instead of the channel.
+		The filter matches fields of the changed object by equality, a slice matches any of its values.
+		Requires Config.MQTTBroker.
+	*/
+	Watch(ctx context.Context, filter map[string]any) *WatchItems
 }
 
 func (a *APIItem) Create(data map[string]any) *CreateItem {
@@ -87,6 +123,18 @@ func (c *CreateItem) DisableFaas(isDisable bool) *CreateItem {
 	return c
 }
 
+func (c *CreateItem) Context(ctx context.Context) *CreateItem {
+	c.ctx = ctx
+	return c
+}
+
+// CheckStatus makes Exec fail with *StatusError when the server answers with a
+// non-2xx status. By default such responses are decoded like successful ones.
+func (c *CreateItem) CheckStatus(check bool) *CreateItem {
+	c.checkStatus = check
+	return c
+}
+
 func (c *CreateItem) Exec() (Datas, Response, error) {
 	var (
 		response = Response{
@@ -103,7 +151,7 @@ func (c *CreateItem) Exec() (Datas, Response, error) {
 		"X-API-KEY":     appId,
 	}
 
-	createObjectResponseInByte, err := DoRequest(url, http.MethodPost, c.data, header)
+	createObjectResponseInByte, err := requestChecking(c.checkStatus)(orBackground(c.ctx), c.config, requestInfo{operation: "items.create", collection: c.collection}, url, http.MethodPost, c.data, header)
 	if err != nil {
 		response.Data = map[string]any{"description": string(createObjectResponseInByte), "message": "Can't send request", "error": err.Error()}
 		response.Status = "error"
@@ -134,6 +182,18 @@ func (a *UpdateItem) DisableFaas(isDisable bool) *UpdateItem {
 	return a
 }
 
+func (u *UpdateItem) Context(ctx context.Context) *UpdateItem {
+	u.ctx = ctx
+	return u
+}
+
+// CheckStatus makes ExecSingle and ExecMultiple fail with *StatusError when the server
+// answers with a non-2xx status. By default such responses are decoded like successful ones.
+func (u *UpdateItem) CheckStatus(check bool) *UpdateItem {
+	u.checkStatus = check
+	return u
+}
+
 func (u *UpdateItem) ExecSingle() (ClientApiUpdateResponse, Response, error) {
 	var (
 		response = Response{
@@ -150,7 +210,7 @@ func (u *UpdateItem) ExecSingle() (ClientApiUpdateResponse, Response, error) {
 		"X-API-KEY":     appId,
 	}
 
-	updateObjectResponseInByte, err := DoRequest(url, http.MethodPut, u.data, header)
+	updateObjectResponseInByte, err := requestChecking(u.checkStatus)(orBackground(u.ctx), u.config, requestInfo{operation: "items.update", collection: u.collection}, url, http.MethodPut, u.data, header)
 	if err != nil {
 		response.Data = map[string]any{"description": string(updateObjectResponseInByte), "message": "Error while updating object", "error": err.Error()}
 		response.Status = "error"
@@ -182,7 +242,7 @@ func (a *UpdateItem) ExecMultiple() (ClientApiMultipleUpdateResponse, Response,
 		"X-API-KEY":     appId,
 	}
 
-	multipleUpdateObjectsResponseInByte, err := DoRequest(url, http.MethodPatch, a.data, header)
+	multipleUpdateObjectsResponseInByte, err := requestChecking(a.checkStatus)(orBackground(a.ctx), a.config, requestInfo{operation: "items.update_multiple", collection: a.collection}, url, http.MethodPatch, a.data, header)
 	if err != nil {
 		response.Data = map[string]any{"description": string(multipleUpdateObjectsResponseInByte), "message": "Error while multiple updating objects", "error": err.Error()}
 		response.Status = "error"
@@ -220,9 +280,22 @@ func (a *DeleteItem) Multiple(ids []string) *DeleteMultipleItem {
 		config:      a.config,
 		disableFaas: a.disableFaas,
 		ids:         ids,
+		checkStatus: a.checkStatus,
 	}
 }
 
+func (a *DeleteItem) Context(ctx context.Context) *DeleteItem {
+	a.ctx = ctx
+	return a
+}
+
+// CheckStatus makes Exec fail with *StatusError when the server answers with a
+// non-2xx status. By default such responses are decoded like successful ones.
+func (a *DeleteItem) CheckStatus(check bool) *DeleteItem {
+	a.checkStatus = check
+	return a
+}
+
 func (a *DeleteItem) Exec() (Response, error) {
 	var (
 		response = Response{
@@ -238,7 +311,7 @@ func (a *DeleteItem) Exec() (Response, error) {
 		"X-API-KEY":     appId,
 	}
 
-	_, err := DoRequest(url, http.MethodDelete, Request{Data: map[string]any{}}, header)
+	_, err := requestChecking(a.checkStatus)(orBackground(a.ctx), a.config, requestInfo{operation: "items.delete", collection: a.collection}, url, http.MethodDelete, Request{Data: map[string]any{}}, header)
 	if err != nil {
 		response.Data = map[string]any{"message": "Error while deleting object", "error": err.Error()}
 		response.Status = "error"
@@ -248,6 +321,18 @@ func (a *DeleteItem) Exec() (Response, error) {
 	return response, nil
 }
 
+func (a *DeleteMultipleItem) Context(ctx context.Context) *DeleteMultipleItem {
+	a.ctx = ctx
+	return a
+}
+
+// CheckStatus makes Exec fail with *StatusError when the server answers with a
+// non-2xx status. By default such responses are decoded like successful ones.
+func (a *DeleteMultipleItem) CheckStatus(check bool) *DeleteMultipleItem {
+	a.checkStatus = check
+	return a
+}
+
 func (a *DeleteMultipleItem) Exec() (Response, error) {
 	var (
 		response = Response{
@@ -269,7 +354,7 @@ func (a *DeleteMultipleItem) Exec() (Response, error) {
 		return response, fmt.Errorf("ids is empty")
 	}
 
-	_, err := DoRequest(url, http.MethodDelete, map[string]any{"ids": a.ids}, header)
+	_, err := requestChecking(a.checkStatus)(orBackground(a.ctx), a.config, requestInfo{operation: "items.delete_multiple", collection: a.collection}, url, http.MethodDelete, map[string]any{"ids": a.ids}, header)
 	if err != nil {
 		response.Data = map[string]any{"message": "Error while deleting objects", "error": err.Error()}
 		response.Status = "error"
@@ -288,6 +373,18 @@ func (a *APIItem) GetSingle(id string) *GetSingleItem {
 	}
 }
 
+func (a *GetSingleItem) Context(ctx context.Context) *GetSingleItem {
+	a.ctx = ctx
+	return a
+}
+
+// CheckStatus makes Exec fail with *StatusError when the server answers with a
+// non-2xx status. By default such responses are decoded like successful ones.
+func (a *GetSingleItem) CheckStatus(check bool) *GetSingleItem {
+	a.checkStatus = check
+	return a
+}
+
 func (a *GetSingleItem) Exec() (ClientApiResponse, Response, error) {
 	if a.guid == "" {
 		return ClientApiResponse{}, Response{Status: "error", Data: map[string]any{"message": "guid is empty"}}, fmt.Errorf("guid is empty")
@@ -306,7 +403,7 @@ func (a *GetSingleItem) Exec() (ClientApiResponse, Response, error) {
 		"X-API-KEY":     appId,
 	}
 
-	resByte, err := DoRequest(url, http.MethodGet, nil, header)
+	resByte, err := requestChecking(a.checkStatus)(orBackground(a.ctx), a.config, requestInfo{operation: "items.get_single", collection: a.collection}, url, http.MethodGet, nil, header)
 	if err != nil {
 		response.Data = map[string]any{"description": string(resByte), "message": "Can't sent request", "error": err.Error()}
 		response.Status = "error"
@@ -377,9 +474,10 @@ func (a *GetListItem) ViewFields(fields []string) *GetListItem {
 
 func (a *GetListItem) Pipelines(query map[string]any) *GetListAggregation {
 	return &GetListAggregation{
-		collection: a.collection,
-		config:     a.config,
-		request:    Request{Data: query},
+		collection:  a.collection,
+		config:      a.config,
+		request:     Request{Data: query},
+		checkStatus: a.checkStatus,
 	}
 }
 
@@ -388,6 +486,18 @@ func (a *GetListItem) WithRelations(with bool) *GetListItem {
 	return a
 }
 
+func (a *GetListItem) Context(ctx context.Context) *GetListItem {
+	a.ctx = ctx
+	return a
+}
+
+// CheckStatus makes Exec fail with *StatusError when the server answers with a
+// non-2xx status. By default such responses are decoded like successful ones.
+func (a *GetListItem) CheckStatus(check bool) *GetListItem {
+	a.checkStatus = check
+	return a
+}
+
 func (a *GetListItem) Exec() (GetListClientApiResponse, Response, error) {
 	var (
 		response = Response{Status: "done"}
@@ -420,7 +530,7 @@ func (a *GetListItem) Exec() (GetListClientApiResponse, Response, error) {
 		"X-API-KEY":     appId,
 	}
 
-	getListResponseInByte, err := DoRequest(url, http.MethodGet, nil, header)
+	getListResponseInByte, err := requestChecking(a.checkStatus)(orBackground(a.ctx), a.config, requestInfo{operation: "items.get_list", collection: a.collection, page: a.page, limit: a.limit}, url, http.MethodGet, nil, header)
 	if err != nil {
 		response.Data = map[string]any{"description": string(getListResponseInByte), "message": "Can't sent request", "error": err.Error()}
 		response.Status = "error"
@@ -437,6 +547,18 @@ func (a *GetListItem) Exec() (GetListClientApiResponse, Response, error) {
 	return listSlim, response, nil
 }
 
+func (a *GetListAggregation) Context(ctx context.Context) *GetListAggregation {
+	a.ctx = ctx
+	return a
+}
+
+// CheckStatus makes ExecAggregation fail with *StatusError when the server answers with a
+// non-2xx status. By default such responses are decoded like successful ones.
+func (a *GetListAggregation) CheckStatus(check bool) *GetListAggregation {
+	a.checkStatus = check
+	return a
+}
+
 func (a *GetListAggregation) ExecAggregation() (GetListAggregationClientApiResponse, Response, error) {
 	var (
 		response           = Response{Status: "done"}
@@ -451,7 +573,7 @@ func (a *GetListAggregation) ExecAggregation() (GetListAggregationClientApiRespo
 		"X-API-KEY":     appId,
 	}
 
-	getListAggregationResponseInByte, err := DoRequest(url, http.MethodPost, a.request, header)
+	getListAggregationResponseInByte, err := requestChecking(a.checkStatus)(orBackground(a.ctx), a.config, requestInfo{operation: "items.get_list_aggregation", collection: a.collection}, url, http.MethodPost, a.request, header)
 	if err != nil {
 		response.Data = map[string]any{"description": string(getListAggregationResponseInByte), "message": "Can't sent request", "error": err.Error()}
 		response.Status = "error"
@@ -467,3 +589,70 @@ func (a *GetListAggregation) ExecAggregation() (GetListAggregationClientApiRespo
 
 	return getListAggregation, response, nil
 }
+
+// ATTACH FILE EXEC
+func (a *APIItem) AttachFile(guid, field, source string) *AttachFile {
+	return &AttachFile{
+		collection:  a.collection,
+		config:      a.config,
+		guid:        guid,
+		field:       field,
+		source:      source,
+		folderName:  "Media",
+		disableFaas: true,
+	}
+}
+
+func (a *AttachFile) DisableFaas(isDisable bool) *AttachFile {
+	a.disableFaas = isDisable
+	return a
+}
+
+func (a *AttachFile) FolderName(name string) *AttachFile {
+	if name != "" {
+		a.folderName = name
+	}
+	return a
+}
+
+// Context sets the context Exec runs with, canceling it aborts the upload and the update.
+// The rollback of a failed update runs even if ctx is canceled.
+func (a *AttachFile) Context(ctx context.Context) *AttachFile {
+	a.ctx = ctx
+	return a
+}
+
+func (a *AttachFile) Exec() (AttachFileResponse, Response, error) {
+	var (
+		ctx   = orBackground(a.ctx)
+		files = &APIFiles{config: a.config}
+		items = &APIItem{collection: a.collection, config: a.config}
+	)
+
+	if a.guid == "" || a.field == "" {
+		return AttachFileResponse{}, Response{Status: "error", Data: map[string]any{"message": "guid or field is empty"}}, fmt.Errorf("guid or field is empty")
+	}
+
+	file, response, err := files.Upload(a.source).FolderName(a.folderName).Context(ctx).Exec()
+	if err != nil {
+		return AttachFileResponse{}, response, err
+	}
+
+	updateBody := map[string]any{
+		"guid":  a.guid,
+		a.field: file.Data.Link,
+	}
+
+	// a rejected update is rolled back like a failed request
+	item, response, err := items.Update(updateBody).DisableFaas(a.disableFaas).CheckStatus(true).Context(ctx).ExecSingle()
+	if err != nil {
+		_, rollbackErr := files.Delete(file.Data.ID).Context(context.WithoutCancel(ctx)).Exec()
+		if rollbackErr != nil {
+			response.Data["rollback_error"] = rollbackErr.Error()
+			err = errors.Join(err, fmt.Errorf("deleting uploaded file %s: %w", file.Data.ID, rollbackErr))
+		}
+		return AttachFileResponse{}, response, err
+	}
+
+	return AttachFileResponse{File: file, Item: item}, response, nil
+}
@@ -0,0 +1,121 @@
+package ucodesdk
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// attachFileServer stores uploads and updates objects of order that exist
+type attachFileServer struct {
+	mu         sync.Mutex
+	files      map[string]bool
+	orders     map[string]map[string]any
+	deleteFail bool
+}
+
+func (s *attachFileServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/v1/files/folder_upload":
+		s.files["file-1"] = true
+
+		var resp CreateFileResponse
+		resp.Data.ID = "file-1"
+		resp.Data.Link = "https://cdn.test/file-1.pdf"
+		json.NewEncoder(w).Encode(resp)
+	case r.Method == http.MethodPut && r.URL.Path == "/v2/items/order":
+		var body ActionBody
+		json.NewDecoder(r.Body).Decode(&body)
+
+		order, ok := s.orders[body.Body["guid"].(string)]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]any{"status": "error", "description": "object not found"})
+			return
+		}
+
+		for key, value := range body.Body {
+			order[key] = value
+		}
+		json.NewEncoder(w).Encode(map[string]any{"status": "OK", "data": map[string]any{"data": order}})
+	case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/v1/files/"):
+		if s.deleteFail {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		delete(s.files, strings.TrimPrefix(r.URL.Path, "/v1/files/"))
+		json.NewEncoder(w).Encode(map[string]any{"status": "OK"})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (s *attachFileServer) fileCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.files)
+}
+
+func TestAttachFile(t *testing.T) {
+	fake := &attachFileServer{
+		files:  map[string]bool{},
+		orders: map[string]map[string]any{"o1": {"guid": "o1"}},
+	}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "invoice.pdf")
+	assert.NoError(t, os.WriteFile(path, []byte("invoice"), 0o644))
+
+	api := New(&Config{BaseURL: server.URL})
+
+	result, response, err := api.Items("order").AttachFile("o1", "invoice", path).Exec()
+	assert.NoError(t, err)
+	assert.Equal(t, "done", response.Status)
+	assert.Equal(t, "file-1", result.File.Data.ID)
+	assert.Equal(t, "https://cdn.test/file-1.pdf", result.Item.Data.Data["invoice"])
+	assert.Equal(t, 1, fake.fileCount())
+}
+
+func TestAttachFileRollback(t *testing.T) {
+	fake := &attachFileServer{
+		files:  map[string]bool{},
+		orders: map[string]map[string]any{},
+	}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "invoice.pdf")
+	assert.NoError(t, os.WriteFile(path, []byte("invoice"), 0o644))
+
+	api := New(&Config{BaseURL: server.URL})
+
+	// the server rejects the update of a missing object, the upload is deleted
+	_, response, err := api.Items("order").AttachFile("missing", "invoice", path).Exec()
+	var statusErr *StatusError
+	assert.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, http.StatusNotFound, statusErr.StatusCode)
+	assert.Equal(t, "error", response.Status)
+	assert.Equal(t, 0, fake.fileCount())
+
+	// a failed rollback is reported together with the update error
+	fake.mu.Lock()
+	fake.deleteFail = true
+	fake.mu.Unlock()
+
+	_, response, err = api.Items("order").AttachFile("missing", "invoice", path).Exec()
+	assert.ErrorAs(t, err, &statusErr)
+	assert.ErrorContains(t, err, "deleting uploaded file file-1")
+	assert.Contains(t, response.Data["rollback_error"], "status 500")
+	assert.Equal(t, 1, fake.fileCount())
+}
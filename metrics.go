@@ -0,0 +1,21 @@
+package ucodesdk
+
+import "time"
+
+/*
+Metrics receives measurements of SDK requests, set it as Config.Metrics.
+The metrics package has Prometheus and expvar implementations.
+
+Operations are named like spans and log records: items.create, files.upload, ...
+Status is 0 when the request failed without a response.
+*/
+type Metrics interface {
+	// RequestStarted and RequestFinished are called around every attempt of a request
+	RequestStarted(operation string)
+	RequestFinished(operation string, status int, err error, latency time.Duration)
+	// RequestRetried is called before a request is sent again, see Config.MaxRetries
+	RequestRetried(operation string)
+	// BytesUploaded is called with the file size of a successful upload, the
+	// multipart encoding around it isn't counted
+	BytesUploaded(operation string, bytes int64)
+}
@@ -0,0 +1,105 @@
+package metrics
+
+import (
+	"expvar"
+	"fmt"
+	"sync"
+	"time"
+
+	sdk "github.com/ucode-io/ucode_sdk"
+)
+
+type Expvar struct {
+	requests *expvar.Map
+	errors   *expvar.Map
+	latency  *expvar.Map
+	retries  *expvar.Map
+	inFlight *expvar.Map
+	uploaded *expvar.Map
+
+	// mu guards creating latency histograms
+	mu sync.Mutex
+}
+
+var _ sdk.Metrics = (*Expvar)(nil)
+
+/*
+NewExpvar publishes the SDK metrics as the expvar map name, served by expvar at /debug/vars:
+
+	{
+		"requests":  {"items.create:200": 10},
+		"errors":    {"items.create:500": 1},
+		"latency":   {"items.create": {"count": 11, "sum_seconds": 1.2, "le_0.1": 9, ...}},
+		"retries":   {"items.get_list": 2},
+		"in_flight": {"items.create": 0},
+		"uploaded_bytes": {"files.upload": 1048576}
+	}
+
+Latency buckets are DefaultBuckets and cumulative like Prometheus histograms.
+It panics if name is already published, like expvar.NewMap.
+*/
+func NewExpvar(name string) *Expvar {
+	root := expvar.NewMap(name)
+
+	e := &Expvar{
+		requests: new(expvar.Map).Init(),
+		errors:   new(expvar.Map).Init(),
+		latency:  new(expvar.Map).Init(),
+		retries:  new(expvar.Map).Init(),
+		inFlight: new(expvar.Map).Init(),
+		uploaded: new(expvar.Map).Init(),
+	}
+
+	root.Set("requests", e.requests)
+	root.Set("errors", e.errors)
+	root.Set("latency", e.latency)
+	root.Set("retries", e.retries)
+	root.Set("in_flight", e.inFlight)
+	root.Set("uploaded_bytes", e.uploaded)
+
+	return e
+}
+
+func (e *Expvar) RequestStarted(operation string) {
+	e.inFlight.Add(operation, 1)
+}
+
+func (e *Expvar) RequestFinished(operation string, status int, err error, latency time.Duration) {
+	key := operation + ":" + statusLabel(status, err)
+
+	e.inFlight.Add(operation, -1)
+	e.requests.Add(key, 1)
+
+	if failed(status, err) {
+		e.errors.Add(key, 1)
+	}
+
+	e.observe(operation, latency.Seconds())
+}
+
+func (e *Expvar) RequestRetried(operation string) {
+	e.retries.Add(operation, 1)
+}
+
+func (e *Expvar) BytesUploaded(operation string, bytes int64) {
+	e.uploaded.Add(operation, bytes)
+}
+
+func (e *Expvar) observe(operation string, seconds float64) {
+	e.mu.Lock()
+	histogram, ok := e.latency.Get(operation).(*expvar.Map)
+	if !ok {
+		histogram = new(expvar.Map).Init()
+		e.latency.Set(operation, histogram)
+	}
+	e.mu.Unlock()
+
+	histogram.Add("count", 1)
+	histogram.AddFloat("sum_seconds", seconds)
+
+	for _, bucket := range DefaultBuckets {
+		if seconds <= bucket {
+			histogram.Add(fmt.Sprintf("le_%g", bucket), 1)
+		}
+	}
+}
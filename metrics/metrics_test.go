@@ -0,0 +1,132 @@
+package metrics
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdk "github.com/ucode-io/ucode_sdk"
+)
+
+func newServer(t *testing.T) *httptest.Server {
+	var calls atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet && calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"status":"OK","data":{"id":"file-1"}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func exercise(t *testing.T, server *httptest.Server, metrics sdk.Metrics) {
+	api := sdk.New(&sdk.Config{BaseURL: server.URL, MaxRetries: 1, Metrics: metrics})
+
+	_, _, err := api.Items("order").GetList().Exec()
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "report.txt")
+	require.NoError(t, os.WriteFile(path, bytes.Repeat([]byte("a"), 1000), 0o600))
+
+	_, _, err = api.Files().Upload(path).Exec()
+	require.NoError(t, err)
+}
+
+func TestPrometheus(t *testing.T) {
+	var (
+		registry = prometheus.NewRegistry()
+		metrics  = NewPrometheus(registry)
+	)
+
+	exercise(t, newServer(t), metrics)
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.requests.WithLabelValues("items.get_list", "503")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.requests.WithLabelValues("items.get_list", "200")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.errors.WithLabelValues("items.get_list", "503")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.retries.WithLabelValues("items.get_list")))
+	assert.Equal(t, 0.0, testutil.ToFloat64(metrics.inFlight.WithLabelValues("items.get_list")))
+	assert.Equal(t, 1000.0, testutil.ToFloat64(metrics.uploaded.WithLabelValues("files.upload")))
+	assert.Equal(t, 2, testutil.CollectAndCount(metrics.latency))
+
+	assert.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(`
+# HELP ucode_request_retries_total Retried Ucode API requests.
+# TYPE ucode_request_retries_total counter
+ucode_request_retries_total{operation="items.get_list"} 1
+`), "ucode_request_retries_total"))
+}
+
+func TestPrometheusFileRequest(t *testing.T) {
+	var (
+		metrics = NewPrometheus(prometheus.NewRegistry())
+		server  = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"status":"error"}`))
+		}))
+		api = sdk.New(&sdk.Config{BaseURL: server.URL, Metrics: metrics})
+	)
+	defer server.Close()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", "report.txt")
+	require.NoError(t, err)
+	part.Write([]byte("report"))
+	require.NoError(t, writer.Close())
+
+	// the response is returned whatever its status, a rejected upload counts no bytes
+	response, err := api.DoFileRequest(server.URL+"/v1/files", http.MethodPost, nil, body, writer)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"status":"error"}`, string(response))
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.requests.WithLabelValues("file_request", "400")))
+	assert.Equal(t, 0.0, testutil.ToFloat64(metrics.uploaded.WithLabelValues("file_request")))
+}
+
+func TestExpvar(t *testing.T) {
+	metrics := NewExpvar("ucode_sdk_test")
+
+	exercise(t, newServer(t), metrics)
+
+	var vars struct {
+		Requests map[string]int                `json:"requests"`
+		Errors   map[string]int                `json:"errors"`
+		Latency  map[string]map[string]float64 `json:"latency"`
+		Retries  map[string]int                `json:"retries"`
+		InFlight map[string]int                `json:"in_flight"`
+		Uploaded map[string]int                `json:"uploaded_bytes"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(metricsVar(t)), &vars))
+
+	assert.Equal(t, map[string]int{"items.get_list:503": 1, "items.get_list:200": 1, "files.upload:200": 1}, vars.Requests)
+	assert.Equal(t, map[string]int{"items.get_list:503": 1}, vars.Errors)
+	assert.Equal(t, map[string]int{"items.get_list": 1}, vars.Retries)
+	assert.Equal(t, 0, vars.InFlight["items.get_list"])
+	assert.Equal(t, 1000, vars.Uploaded["files.upload"])
+	assert.Equal(t, 2.0, vars.Latency["items.get_list"]["count"])
+	assert.Equal(t, 2.0, vars.Latency["items.get_list"]["le_10"])
+}
+
+func metricsVar(t *testing.T) string {
+	recorder := httptest.NewRecorder()
+	http.DefaultServeMux.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/debug/vars", nil))
+
+	var all map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &all))
+
+	return string(all["ucode_sdk_test"])
+}
@@ -0,0 +1,115 @@
+// Package metrics implements ucodesdk.Metrics with Prometheus and expvar:
+//
+//	api := sdk.New(&sdk.Config{
+//		BaseURL: baseURL,
+//		AppId:   appID,
+//		Metrics: metrics.NewPrometheus(prometheus.DefaultRegisterer),
+//	})
+//
+// Both expose request and error counts by operation and status, latency
+// histograms, retries, in-flight requests and bytes uploaded.
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	sdk "github.com/ucode-io/ucode_sdk"
+)
+
+// DefaultBuckets are the latency histogram buckets in seconds
+var DefaultBuckets = []float64{0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+type Prometheus struct {
+	requests *prometheus.CounterVec
+	errors   *prometheus.CounterVec
+	latency  *prometheus.HistogramVec
+	retries  *prometheus.CounterVec
+	inFlight *prometheus.GaugeVec
+	uploaded *prometheus.CounterVec
+}
+
+var _ sdk.Metrics = (*Prometheus)(nil)
+
+/*
+NewPrometheus registers the SDK metrics with registerer:
+
+	ucode_requests_total{operation, status}
+	ucode_request_errors_total{operation, status}     network errors and statuses >= 400
+	ucode_request_duration_seconds{operation}
+	ucode_request_retries_total{operation}
+	ucode_requests_in_flight{operation}
+	ucode_uploaded_bytes_total{operation}
+
+status is "error" for requests that failed without a response. It panics if the
+metrics are already registered, like prometheus.MustRegister.
+*/
+func NewPrometheus(registerer prometheus.Registerer) *Prometheus {
+	p := &Prometheus{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "ucode_requests_total",
+			Help: "Requests sent to the Ucode API.",
+		}, []string{"operation", "status"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "ucode_request_errors_total",
+			Help: "Requests to the Ucode API that failed with a network error or an error status.",
+		}, []string{"operation", "status"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "ucode_request_duration_seconds",
+			Help:    "Latency of Ucode API requests.",
+			Buckets: DefaultBuckets,
+		}, []string{"operation"}),
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "ucode_request_retries_total",
+			Help: "Retried Ucode API requests.",
+		}, []string{"operation"}),
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "ucode_requests_in_flight",
+			Help: "Ucode API requests waiting for a response.",
+		}, []string{"operation"}),
+		uploaded: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "ucode_uploaded_bytes_total",
+			Help: "Size of files uploaded to the Ucode API.",
+		}, []string{"operation"}),
+	}
+
+	registerer.MustRegister(p.requests, p.errors, p.latency, p.retries, p.inFlight, p.uploaded)
+
+	return p
+}
+
+func (p *Prometheus) RequestStarted(operation string) {
+	p.inFlight.WithLabelValues(operation).Inc()
+}
+
+func (p *Prometheus) RequestFinished(operation string, status int, err error, latency time.Duration) {
+	label := statusLabel(status, err)
+
+	p.inFlight.WithLabelValues(operation).Dec()
+	p.requests.WithLabelValues(operation, label).Inc()
+	p.latency.WithLabelValues(operation).Observe(latency.Seconds())
+
+	if failed(status, err) {
+		p.errors.WithLabelValues(operation, label).Inc()
+	}
+}
+
+func (p *Prometheus) RequestRetried(operation string) {
+	p.retries.WithLabelValues(operation).Inc()
+}
+
+func (p *Prometheus) BytesUploaded(operation string, bytes int64) {
+	p.uploaded.WithLabelValues(operation).Add(float64(bytes))
+}
+
+func statusLabel(status int, err error) string {
+	if status == 0 || err != nil {
+		return "error"
+	}
+	return strconv.Itoa(status)
+}
+
+func failed(status int, err error) bool {
+	return err != nil || status == 0 || status >= 400
+}
@@ -1,5 +1,11 @@
 package ucodesdk
 
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
 type (
 	Request struct {
 		Data     map[string]any `json:"data"`
@@ -78,6 +84,12 @@ type (
 		} `json:"data"`
 	}
 
+	// AttachFileResponse This is upload and update response >>>>> ATTACH_FILE
+	AttachFileResponse struct {
+		File CreateFileResponse      `json:"file"`
+		Item ClientApiUpdateResponse `json:"item"`
+	}
+
 	ResponseError struct {
 		StatusCode         int
 		Description        any
@@ -99,12 +111,44 @@ type AuthRequest struct {
 type APIItem struct {
 	collection string
 	config     *Config
+	mqtt       *mqttManager
 }
 
-type CreateItem struct {
+type PubSub struct {
+	mqtt  *mqttManager
+	codec Codec
+	qos   byte
+}
+
+type MQTTRPC struct {
+	mqtt           *mqttManager
+	inbox          *rpcInbox
+	timeout        time.Duration
+	concurrency    int
+	handlerTimeout time.Duration
+	handlers       map[string]RPCHandler
+	logger         *slog.Logger
+}
+
+type WatchItems struct {
 	collection string
 	config     *Config
-	data       ActionBody
+	mqtt       *mqttManager
+	ctx        context.Context
+	filter     map[string]any
+	events     []ItemEventType
+	buffer     int
+	qos        byte
+	topic      string
+	handler    func(ItemEvent)
+}
+
+type CreateItem struct {
+	collection  string
+	config      *Config
+	data        ActionBody
+	checkStatus bool
+	ctx         context.Context
 }
 
 type DeleteItem struct {
@@ -112,6 +156,8 @@ type DeleteItem struct {
 	config      *Config
 	disableFaas bool
 	id          string
+	checkStatus bool
+	ctx         context.Context
 }
 
 type DeleteMultipleItem struct {
@@ -119,52 +165,77 @@ type DeleteMultipleItem struct {
 	config      *Config
 	disableFaas bool
 	ids         []string
+	checkStatus bool
+	ctx         context.Context
 }
 
 type UpdateItem struct {
-	collection string
-	config     *Config
-	data       ActionBody
+	collection  string
+	config      *Config
+	data        ActionBody
+	checkStatus bool
+	ctx         context.Context
+}
+
+type AttachFile struct {
+	collection  string
+	config      *Config
+	guid        string
+	field       string
+	source      string
+	folderName  string
+	disableFaas bool
+	ctx         context.Context
 }
 
 type GetSingleItem struct {
-	collection string
-	config     *Config
-	guid       string
+	collection  string
+	config      *Config
+	guid        string
+	checkStatus bool
+	ctx         context.Context
 }
 
 type GetListItem struct {
-	collection string
-	config     *Config
-	request    Request
-	limit      int
-	page       int
+	collection  string
+	config      *Config
+	request     Request
+	limit       int
+	page        int
+	checkStatus bool
+	ctx         context.Context
 }
 
 type GetListAggregation struct {
-	collection string
-	config     *Config
-	request    Request
+	collection  string
+	config      *Config
+	request     Request
+	checkStatus bool
+	ctx         context.Context
 }
 
 type Register struct {
 	config *Config
 	data   AuthRequest
+	ctx    context.Context
 }
 
 type ResetPassword struct {
 	config *Config
 	data   AuthRequest
+	ctx    context.Context
 }
 
 type Login struct {
 	config *Config
 	data   AuthRequest
+	ctx    context.Context
 }
 
 type SendCode struct {
 	config *Config
 	data   AuthRequest
+	ctx    context.Context
 }
 
 type APIAuth struct {
@@ -176,19 +247,97 @@ type APIFiles struct {
 }
 
 type UploadFile struct {
-	config *Config
-	path   string
+	config     *Config
+	path       string
+	folderName string
+	dedup      FileDedupCache
+	ctx        context.Context
+}
+
+type UploadDir struct {
+	files *APIFiles
+	dir   string
+	opts  UploadDirOptions
+	ctx   context.Context
+}
+
+// UploadDirOptions configures Files().UploadDir
+type UploadDirOptions struct {
+	// FolderName is the server folder files are uploaded into, default "Media".
+	// Sub directories are appended to it, e.g. "Media/images/icons".
+	FolderName string
+	// Include and Exclude are glob patterns ("*.png", "assets/**/*.svg").
+	// Patterns without "/" are matched against the file name, others against
+	// the slash separated path relative to the uploaded directory.
+	Include []string
+	Exclude []string
+	// Concurrency is the number of parallel uploads, default 4.
+	Concurrency int
+	// ManifestPath is a local JSON file storing checksums of uploaded files.
+	// Files whose checksum did not change since the last upload are skipped.
+	ManifestPath string
+	// Dedup is passed to every upload, see UploadFile.Dedup.
+	Dedup FileDedupCache
 }
 
 type DeleteFile struct {
 	config *Config
 	id     string
+	ctx    context.Context
+}
+
+type SignedURL struct {
+	config *Config
+	id     string
+	ttl    time.Duration
+	ctx    context.Context
+}
+
+type DeleteManyFiles struct {
+	files       *APIFiles
+	ids         []string
+	concurrency int
+	ctx         context.Context
+}
+
+type FindOrphanFiles struct {
+	files             *APIFiles
+	references        map[string][]string
+	folderName        string
+	delete            bool
+	allowNoReferences bool
+	concurrency       int
+	ctx               context.Context
 }
 
 type APIFunction struct {
 	config  *Config
 	request Request
 	path    string
+	headers map[string]string
+	query   map[string]string
+	timeout time.Duration
+	ctx     context.Context
+
+	signingSecret string
+}
+
+type FanOut struct {
+	object      *object
+	calls       []FunctionCall
+	concurrency int
+	failFast    bool
+	ctx         context.Context
+	configure   func(*APIFunction) *APIFunction
+}
+
+type FunctionJob struct {
+	config      *Config
+	path        string
+	id          string
+	header      map[string]string
+	minInterval time.Duration
+	maxInterval time.Duration
 }
 
 type User struct {
@@ -237,8 +386,70 @@ type CreateFileResponse struct {
 		FileNameDownload string `json:"file_name_download"`
 		Link             string `json:"link"`
 		FileSize         int    `json:"file_size"`
+		SHA256           string `json:"sha256,omitempty"`
+		MD5              string `json:"md5,omitempty"`
 	} `json:"data"`
 	CustomMessage string `json:"custom_message"`
+	// Checksums of the uploaded content computed by the SDK while sending it
+	Checksums FileChecksums `json:"checksums"`
+}
+
+type UploadDirResponse struct {
+	Uploaded int                   `json:"uploaded"`
+	Skipped  int                   `json:"skipped"`
+	Failed   int                   `json:"failed"`
+	Files    []UploadDirFileResult `json:"files"`
+}
+
+type UploadDirFileResult struct {
+	Path       string `json:"path"`
+	FolderName string `json:"folder_name"`
+	Status     string `json:"status"` // uploaded, skipped or failed
+	Checksum   string `json:"checksum"`
+	ID         string `json:"id"`
+	Link       string `json:"link"`
+	Error      string `json:"error,omitempty"`
+}
+
+type FileObject struct {
+	ID               string `json:"id"`
+	Title            string `json:"title"`
+	Storage          string `json:"storage"`
+	FileNameDisk     string `json:"file_name_disk"`
+	FileNameDownload string `json:"file_name_download"`
+	Link             string `json:"link"`
+	FileSize         int    `json:"file_size"`
+}
+
+type FileListResponse struct {
+	Status      string `json:"status"`
+	Description string `json:"description"`
+	Data        struct {
+		Files []FileObject `json:"files"`
+		Count int          `json:"count"`
+	} `json:"data"`
+}
+
+type SignedURLResponse struct {
+	Status      string `json:"status"`
+	Description string `json:"description"`
+	Data        struct {
+		URL       string    `json:"url"`
+		ExpiresAt time.Time `json:"expires_at"`
+	} `json:"data"`
+}
+
+type DeleteManyFilesResponse struct {
+	Deleted []string          `json:"deleted"`
+	Failed  map[string]string `json:"failed"` // file id -> error
+}
+
+type OrphanFilesResponse struct {
+	Scanned    int               `json:"scanned"`
+	References int               `json:"references"`
+	Orphans    []FileObject      `json:"orphans"`
+	Deleted    []string          `json:"deleted"`
+	Failed     map[string]string `json:"failed"`
 }
 
 type FunctionResponse struct {
@@ -248,6 +459,19 @@ type FunctionResponse struct {
 	CustomMessage any    `json:"custom_message"`
 }
 
+type FunctionJobResponse struct {
+	Status      string            `json:"status"`
+	Description string            `json:"description"`
+	Data        FunctionJobStatus `json:"data"`
+}
+
+type FunctionJobStatus struct {
+	JobID  string `json:"job_id"`
+	Status string `json:"status"` // pending, running, done, error or canceled
+	Result any    `json:"result"`
+	Error  string `json:"error"`
+}
+
 type LoginWithOptionResponse struct {
 	Status      string `json:"status"`
 	Description string `json:"description"`
@@ -1,20 +1,451 @@
 package ucodesdk
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
 	mqtt "github.com/eclipse/paho.mqtt.golang"
 )
 
+// MQTTState is passed to Config.MQTTOnStateChange when the shared connection changes
+type MQTTState string
+
+const (
+	MQTTConnected    MQTTState = "connected"
+	MQTTDisconnected MQTTState = "disconnected"
+	MQTTReconnecting MQTTState = "reconnecting"
+)
+
+var ErrMQTTNotConfigured = errors.New("mqtt broker is not configured")
+
+/*
+ConnectToMQTT returns the MQTT client shared by the sdk, it is connected on the first call.
+
+The client reconnects with backoff after a lost connection and subscriptions made
+through the sdk are restored. Don't Disconnect it while watches or subscriptions are running.
+*/
 func (u *object) ConnectToMQTT() (mqtt.Client, error) {
-	opts := mqtt.NewClientOptions()
-	opts.AddBroker(u.config.MQTTBroker)
-	opts.SetUsername(u.config.MQTTUsername) // Set your username
-	opts.SetPassword(u.config.MQTTPassword) // Set your password
+	if u.config.MQTTQueuePath == "" {
+		return u.mqtt.connect(context.Background())
+	}
 
-	client := mqtt.NewClient(opts)
+	if _, err := u.mqtt.loadQueue(); err != nil {
+		return nil, err
+	}
 
-	if token := client.Connect(); token.Wait() && token.Error() != nil {
-		return nil, token.Error()
+	client, err := u.mqtt.connect(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	return queuedClient{Client: client, manager: u.mqtt}, nil
+}
+
+// mqttManager lazily creates one MQTT connection and restores its subscriptions after reconnects
+type mqttManager struct {
+	config *Config
+
+	mu         sync.Mutex
+	client     mqtt.Client
+	connecting *mqttAttempt
+	subs       map[string]*mqttSubscription
+	nextID     uint64
+
+	queueOnce sync.Once
+	queue     *mqttQueue
+	queueErr  error
+}
+
+// mqttAttempt is a pending connect or broker subscribe, err is set before done is closed
+type mqttAttempt struct {
+	done chan struct{}
+	err  error
+}
+
+type mqttSubscription struct {
+	qos      byte
+	handlers map[uint64]mqtt.MessageHandler
+	// subscribed is the broker subscribe made for the first handler
+	subscribed *mqttAttempt
+	// closing is the broker unsubscribe after the last handler, set while it is pending
+	closing *mqttAttempt
+}
+
+func newMQTTManager(config *Config) *mqttManager {
+	return &mqttManager{
+		config: config,
+		subs:   map[string]*mqttSubscription{},
+	}
+}
+
+// connect returns the connected client, waiting for startConnect's attempt until ctx is done
+func (m *mqttManager) connect(ctx context.Context) (mqtt.Client, error) {
+	client, attempt, err := m.startConnect()
+	if err != nil || attempt == nil {
+		return client, err
+	}
+
+	select {
+	case <-attempt.done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	if attempt.err != nil {
+		return nil, attempt.err
 	}
 
 	return client, nil
 }
+
+/*
+startConnect returns the client and, unless it is connected, the connect attempt
+in progress, starting one if there is none. The attempt runs without m.mu held,
+so callers that give up waiting for it don't block each other.
+*/
+func (m *mqttManager) startConnect() (mqtt.Client, *mqttAttempt, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.client != nil && m.client.IsConnected() {
+		return m.client, nil, nil
+	}
+
+	if m.config.MQTTBroker == "" {
+		return nil, nil, ErrMQTTNotConfigured
+	}
+
+	if m.client == nil {
+		opts, err := mqttClientOptions(m.config)
+		if err != nil {
+			return nil, nil, err
+		}
+		opts.SetOnConnectHandler(m.onConnect)
+		m.client = mqtt.NewClient(opts)
+	}
+
+	client, attempt := m.client, m.connecting
+	if attempt == nil {
+		attempt = &mqttAttempt{done: make(chan struct{})}
+		m.connecting = attempt
+
+		go func() {
+			token := client.Connect()
+			token.Wait()
+			attempt.err = token.Error()
+
+			m.mu.Lock()
+			m.connecting = nil
+			m.mu.Unlock()
+			close(attempt.done)
+		}()
+	}
+
+	return client, attempt, nil
+}
+
+// onConnect restores subscriptions, the broker forgets them on a clean session
+func (m *mqttManager) onConnect(client mqtt.Client) {
+	m.mu.Lock()
+	topics := make(map[string]byte, len(m.subs))
+	for topic, sub := range m.subs {
+		if sub.closing == nil {
+			topics[topic] = sub.qos
+		}
+	}
+	m.mu.Unlock()
+
+	for topic, qos := range topics {
+		client.Subscribe(topic, qos, m.dispatch(topic))
+	}
+
+	if m.config.MQTTQueuePath != "" {
+		if queue, err := m.loadQueue(); err == nil {
+			go queue.flush(client)
+		}
+	}
+
+	if m.config.MQTTOnStateChange != nil {
+		m.config.MQTTOnStateChange(MQTTConnected, nil)
+	}
+}
+
+// loadQueue reads the offline queue from Config.MQTTQueuePath on the first call
+func (m *mqttManager) loadQueue() (*mqttQueue, error) {
+	m.queueOnce.Do(func() {
+		m.queue, m.queueErr = newMQTTQueue(m.config)
+	})
+	return m.queue, m.queueErr
+}
+
+/*
+publish sends payload and waits for the broker to acknowledge it. With Config.MQTTQueuePath
+set, messages published while the connection is down, or while older messages are
+still queued, are stored in the offline queue and nil is returned right away. The
+connect is started in the background and onConnect replays the queue.
+*/
+func (m *mqttManager) publish(ctx context.Context, topic string, qos byte, retained bool, payload []byte) error {
+	if m.config.MQTTQueuePath == "" {
+		client, err := m.connect(ctx)
+		if err != nil {
+			return err
+		}
+		return waitToken(ctx, client.Publish(topic, qos, retained, payload))
+	}
+
+	queue, err := m.loadQueue()
+	if err != nil {
+		return err
+	}
+
+	client, _, err := m.startConnect()
+	if err != nil {
+		return err
+	}
+
+	if client.IsConnectionOpen() && queue.depth() == 0 {
+		return waitToken(ctx, client.Publish(topic, qos, retained, payload))
+	}
+
+	return m.enqueue(queuedMessage{Topic: topic, QoS: qos, Retained: retained, Payload: payload})
+}
+
+func (m *mqttManager) enqueue(msg queuedMessage) error {
+	if err := m.queue.push(msg); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	client := m.client
+	m.mu.Unlock()
+
+	// the connection may have come back after the caller checked it
+	if client != nil && client.IsConnectionOpen() {
+		go m.queue.flush(client)
+	}
+
+	return nil
+}
+
+/*
+subscribe registers handler for messages on topic and subscribes on the first handler of the topic.
+Several handlers of one topic share a single broker subscription, handlers added while it
+is pending wait for its result and fail with it. The returned function removes the handler
+and unsubscribes after the last one, a subscribe to the topic meanwhile waits for the
+unsubscribe so the broker never drops the new subscription.
+*/
+func (m *mqttManager) subscribe(ctx context.Context, topic string, qos byte, handler mqtt.MessageHandler) (func() error, error) {
+	client, err := m.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	sub, ok := m.subs[topic]
+	for ok && sub.closing != nil {
+		closing := sub.closing
+		m.mu.Unlock()
+
+		select {
+		case <-closing.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+
+		m.mu.Lock()
+		sub, ok = m.subs[topic]
+	}
+
+	m.nextID++
+	id := m.nextID
+
+	if !ok {
+		sub = &mqttSubscription{
+			qos:        qos,
+			handlers:   map[uint64]mqtt.MessageHandler{},
+			subscribed: &mqttAttempt{done: make(chan struct{})},
+		}
+		m.subs[topic] = sub
+		go m.brokerSubscribe(client, topic, sub)
+	}
+	sub.handlers[id] = handler
+	m.mu.Unlock()
+
+	unsubscribe := func() error {
+		return m.unsubscribe(client, topic, sub, id)
+	}
+
+	select {
+	case <-sub.subscribed.done:
+	case <-ctx.Done():
+		// the pending subscribe still reaches the broker, undo it once it does
+		go unsubscribe()
+		return nil, ctx.Err()
+	}
+
+	if err := sub.subscribed.err; err != nil {
+		return nil, fmt.Errorf("subscribing to %s: %w", topic, err)
+	}
+
+	return unsubscribe, nil
+}
+
+// brokerSubscribe subscribes to topic for sub. A failed subscription is removed
+// with all its handlers, so the next subscribe to topic tries again.
+func (m *mqttManager) brokerSubscribe(client mqtt.Client, topic string, sub *mqttSubscription) {
+	token := client.Subscribe(topic, sub.qos, m.dispatch(topic))
+	token.Wait()
+
+	m.mu.Lock()
+	sub.subscribed.err = token.Error()
+	if sub.subscribed.err != nil && m.subs[topic] == sub {
+		delete(m.subs, topic)
+	}
+	m.mu.Unlock()
+
+	close(sub.subscribed.done)
+}
+
+/*
+unsubscribe removes handler id of sub. After the last handler it waits for the broker
+subscribe and unsubscribes if that succeeded, sub stays in m.subs marked as closing
+until then, so subscribe doesn't add handlers to a subscription the broker is dropping.
+*/
+func (m *mqttManager) unsubscribe(client mqtt.Client, topic string, sub *mqttSubscription, id uint64) error {
+	m.mu.Lock()
+	delete(sub.handlers, id)
+	if len(sub.handlers) > 0 || sub.closing != nil {
+		m.mu.Unlock()
+		return nil
+	}
+	closing := &mqttAttempt{done: make(chan struct{})}
+	sub.closing = closing
+	m.mu.Unlock()
+
+	<-sub.subscribed.done
+	if sub.subscribed.err == nil {
+		closing.err = waitToken(context.Background(), client.Unsubscribe(topic))
+	}
+
+	m.mu.Lock()
+	if m.subs[topic] == sub {
+		delete(m.subs, topic)
+	}
+	m.mu.Unlock()
+
+	close(closing.done)
+	return closing.err
+}
+
+func (m *mqttManager) dispatch(topic string) mqtt.MessageHandler {
+	return func(client mqtt.Client, msg mqtt.Message) {
+		// paho routes messages of overlapping subscriptions to every matching route
+		if !TopicMatches(topic, msg.Topic()) {
+			return
+		}
+
+		m.mu.Lock()
+		sub, ok := m.subs[topic]
+		if !ok {
+			m.mu.Unlock()
+			return
+		}
+		handlers := make([]mqtt.MessageHandler, 0, len(sub.handlers))
+		for _, handler := range sub.handlers {
+			handlers = append(handlers, handler)
+		}
+		m.mu.Unlock()
+
+		for _, handler := range handlers {
+			handler(client, msg)
+		}
+	}
+}
+
+func mqttClientOptions(config *Config) (*mqtt.ClientOptions, error) {
+	opts := mqtt.NewClientOptions()
+	opts.AddBroker(config.MQTTBroker)
+	opts.SetUsername(config.MQTTUsername) // Set your username
+	opts.SetPassword(config.MQTTPassword) // Set your password
+	opts.SetClientID(config.MQTTClientID)
+	opts.SetCleanSession(!config.MQTTPersistentSession)
+	opts.SetAutoReconnect(true)
+
+	keepAlive := config.MQTTKeepAlive
+	if keepAlive <= 0 {
+		keepAlive = 30 * time.Second
+	}
+	opts.SetKeepAlive(keepAlive)
+
+	maxReconnect := config.MQTTMaxReconnectInterval
+	if maxReconnect <= 0 {
+		maxReconnect = time.Minute
+	}
+	opts.SetMaxReconnectInterval(maxReconnect)
+
+	tlsConfig, err := mqttTLSConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		opts.SetTLSConfig(tlsConfig)
+	}
+
+	if onStateChange := config.MQTTOnStateChange; onStateChange != nil {
+		opts.SetConnectionLostHandler(func(_ mqtt.Client, err error) {
+			onStateChange(MQTTDisconnected, err)
+		})
+		opts.SetReconnectingHandler(func(mqtt.Client, *mqtt.ClientOptions) {
+			onStateChange(MQTTReconnecting, nil)
+		})
+	}
+
+	return opts, nil
+}
+
+func mqttTLSConfig(config *Config) (*tls.Config, error) {
+	if config.MQTTCAFile == "" && config.MQTTCertFile == "" {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if config.MQTTCAFile != "" {
+		ca, err := os.ReadFile(config.MQTTCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading mqtt ca file: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("mqtt ca file %s has no certificates", config.MQTTCAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if config.MQTTCertFile != "" {
+		cert, err := tls.LoadX509KeyPair(config.MQTTCertFile, config.MQTTKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading mqtt client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// waitToken waits for an MQTT token and returns its error, or the ctx error if ctx is done first
+func waitToken(ctx context.Context, token mqtt.Token) error {
+	select {
+	case <-token.Done():
+		return token.Error()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
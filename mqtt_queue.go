@@ -0,0 +1,312 @@
+package ucodesdk
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+// MQTTDropPolicy decides which message is dropped when the offline queue is full
+type MQTTDropPolicy string
+
+const (
+	MQTTDropOldest MQTTDropPolicy = "drop_oldest"
+	MQTTDropNewest MQTTDropPolicy = "drop_newest"
+)
+
+var ErrMQTTQueueFull = errors.New("mqtt offline queue is full")
+
+type MQTTQueueStats struct {
+	// Depth is the number of messages waiting for the connection
+	Depth    int
+	Dropped  uint64
+	Replayed uint64
+}
+
+type queuedMessage struct {
+	Topic    string `json:"topic,omitempty"`
+	QoS      byte   `json:"qos,omitempty"`
+	Retained bool   `json:"retained,omitempty"`
+	Payload  []byte `json:"payload,omitempty"`
+}
+
+// queueLine is a line of the queue file, a message or a consumed marker
+type queueLine struct {
+	queuedMessage
+	// Consumed removes the oldest message before the marker, it is written
+	// when a message is replayed or dropped by MQTTDropOldest
+	Consumed bool `json:"consumed,omitempty"`
+}
+
+/*
+mqttQueue keeps publishes made while the connection is down in Config.MQTTQueuePath
+and replays them in order after reconnect, so they survive restarts of the process.
+
+The file holds one JSON line per message. Pushes append a line, replayed and
+dropped messages append a consumed marker and stay in the file until it is
+compacted, which happens after a flush empties the queue or once size stale
+messages piled up. A crash between the broker acknowledging a replayed message
+and its marker being written replays that one message again.
+*/
+type mqttQueue struct {
+	path   string
+	size   int
+	policy MQTTDropPolicy
+
+	mu       sync.Mutex
+	messages []queuedMessage
+	dropped  uint64
+	replayed uint64
+	// stale counts dropped or replayed messages still in the file
+	stale int
+
+	flushMu sync.Mutex
+}
+
+func newMQTTQueue(config *Config) (*mqttQueue, error) {
+	q := &mqttQueue{
+		path:   config.MQTTQueuePath,
+		size:   config.MQTTQueueSize,
+		policy: config.MQTTQueueDropPolicy,
+	}
+
+	if q.size <= 0 {
+		q.size = 1000
+	}
+	if q.policy == "" {
+		q.policy = MQTTDropOldest
+	}
+
+	data, err := os.ReadFile(q.path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("reading mqtt queue: %w", err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	for {
+		var line queueLine
+		if err := decoder.Decode(&line); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("reading mqtt queue %s: %w", q.path, err)
+		}
+
+		if !line.Consumed {
+			q.messages = append(q.messages, line.queuedMessage)
+		} else if len(q.messages) > 0 {
+			q.messages = q.messages[1:]
+			q.stale++
+		}
+	}
+
+	// only messages that weren't consumed count against size, a smaller size
+	// than the previous process had drops the excess by the policy
+	if extra := len(q.messages) - q.size; extra > 0 {
+		if q.policy == MQTTDropNewest {
+			q.messages = q.messages[:q.size]
+		} else {
+			q.messages = q.messages[extra:]
+		}
+		q.dropped += uint64(extra)
+
+		if err := q.compact(); err != nil {
+			return nil, err
+		}
+	}
+
+	return q, nil
+}
+
+func (q *mqttQueue) push(msg queuedMessage) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var lines []queueLine
+
+	if len(q.messages) >= q.size {
+		q.dropped++
+		if q.policy == MQTTDropNewest {
+			return ErrMQTTQueueFull
+		}
+		q.messages = q.messages[1:]
+		q.stale++
+		lines = append(lines, queueLine{Consumed: true})
+	}
+
+	q.messages = append(q.messages, msg)
+
+	if q.stale >= q.size {
+		return q.compact()
+	}
+
+	return q.append(append(lines, queueLine{queuedMessage: msg})...)
+}
+
+// flush publishes queued messages in order and stops at the first failure
+func (q *mqttQueue) flush(client mqtt.Client) error {
+	q.flushMu.Lock()
+	defer q.flushMu.Unlock()
+
+	for {
+		q.mu.Lock()
+		if len(q.messages) == 0 {
+			q.mu.Unlock()
+			return nil
+		}
+		msg := q.messages[0]
+		q.mu.Unlock()
+
+		token := client.Publish(msg.Topic, msg.QoS, msg.Retained, msg.Payload)
+		if !token.WaitTimeout(30 * time.Second) {
+			return fmt.Errorf("replaying mqtt message to %s: timeout", msg.Topic)
+		}
+		if err := token.Error(); err != nil {
+			return fmt.Errorf("replaying mqtt message to %s: %w", msg.Topic, err)
+		}
+
+		q.mu.Lock()
+		q.messages = q.messages[1:]
+		q.replayed++
+		q.stale++
+
+		var err error
+		if len(q.messages) == 0 || q.stale >= q.size {
+			err = q.compact()
+		} else {
+			err = q.append(queueLine{Consumed: true})
+		}
+		q.mu.Unlock()
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (q *mqttQueue) depth() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.messages)
+}
+
+func (q *mqttQueue) stats() MQTTQueueStats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return MQTTQueueStats{Depth: len(q.messages), Dropped: q.dropped, Replayed: q.replayed}
+}
+
+// append adds lines to the file, q.mu must be held
+func (q *mqttQueue) append(lines ...queueLine) error {
+	var buf bytes.Buffer
+	for _, line := range lines {
+		data, err := json.Marshal(line)
+		if err != nil {
+			return err
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+
+	file, err := os.OpenFile(q.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("saving mqtt queue: %w", err)
+	}
+
+	if _, err := file.Write(buf.Bytes()); err != nil {
+		file.Close()
+		return fmt.Errorf("saving mqtt queue: %w", err)
+	}
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("saving mqtt queue: %w", err)
+	}
+
+	return nil
+}
+
+// compact writes the queued messages to a temporary file and renames it over
+// the queue, dropping stale messages and consumed markers. q.mu must be held.
+func (q *mqttQueue) compact() error {
+	var buf bytes.Buffer
+	for _, msg := range q.messages {
+		data, err := json.Marshal(msg)
+		if err != nil {
+			return err
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(q.path), filepath.Base(q.path)+".*")
+	if err != nil {
+		return fmt.Errorf("saving mqtt queue: %w", err)
+	}
+
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("saving mqtt queue: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("saving mqtt queue: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), q.path); err != nil {
+		return fmt.Errorf("saving mqtt queue: %w", err)
+	}
+
+	q.stale = 0
+	return nil
+}
+
+// queuedClient is returned by ConnectToMQTT when the offline queue is enabled,
+// publishes made while the connection is down go to the queue
+type queuedClient struct {
+	mqtt.Client
+	manager *mqttManager
+}
+
+func (c queuedClient) Publish(topic string, qos byte, retained bool, payload any) mqtt.Token {
+	var data []byte
+	switch p := payload.(type) {
+	case []byte:
+		data = p
+	case string:
+		data = []byte(p)
+	default:
+		return c.Client.Publish(topic, qos, retained, payload)
+	}
+
+	if c.Client.IsConnectionOpen() && c.manager.queue.depth() == 0 {
+		return c.Client.Publish(topic, qos, retained, data)
+	}
+
+	return newDoneToken(c.manager.enqueue(queuedMessage{Topic: topic, QoS: qos, Retained: retained, Payload: data}))
+}
+
+// doneToken is an already completed mqtt.Token
+type doneToken struct {
+	done chan struct{}
+	err  error
+}
+
+func newDoneToken(err error) mqtt.Token {
+	done := make(chan struct{})
+	close(done)
+	return &doneToken{done: done, err: err}
+}
+
+func (t *doneToken) Wait() bool                     { return true }
+func (t *doneToken) WaitTimeout(time.Duration) bool { return true }
+func (t *doneToken) Done() <-chan struct{}          { return t.done }
+func (t *doneToken) Error() error                   { return t.err }
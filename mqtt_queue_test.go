@@ -0,0 +1,131 @@
+package ucodesdk
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/stretchr/testify/assert"
+)
+
+type publishClient struct {
+	mqtt.Client
+	published []string
+	err       error
+	// limit fails publishes once limit messages were published, if set
+	limit int
+}
+
+func (c *publishClient) Publish(topic string, qos byte, retained bool, payload any) mqtt.Token {
+	if c.err != nil {
+		return newDoneToken(c.err)
+	}
+	if c.limit > 0 && len(c.published) >= c.limit {
+		return newDoneToken(errors.New("connection lost"))
+	}
+	c.published = append(c.published, topic+" "+string(payload.([]byte)))
+	return newDoneToken(nil)
+}
+
+func TestMQTTQueue(t *testing.T) {
+	config := &Config{MQTTQueuePath: filepath.Join(t.TempDir(), "queue.json"), MQTTQueueSize: 2}
+
+	queue, err := newMQTTQueue(config)
+	assert.NoError(t, err)
+
+	assert.NoError(t, queue.push(queuedMessage{Topic: "a", Payload: []byte("1")}))
+	assert.NoError(t, queue.push(queuedMessage{Topic: "b", Payload: []byte("2")}))
+	assert.NoError(t, queue.push(queuedMessage{Topic: "c", Payload: []byte("3")}))
+	assert.Equal(t, MQTTQueueStats{Depth: 2, Dropped: 1}, queue.stats())
+
+	// the queue survives a restart
+	queue, err = newMQTTQueue(config)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, queue.depth())
+
+	client := &publishClient{err: errors.New("not connected")}
+	assert.Error(t, queue.flush(client))
+	assert.Equal(t, 2, queue.depth())
+
+	client.err = nil
+	assert.NoError(t, queue.flush(client))
+	assert.Equal(t, []string{"b 2", "c 3"}, client.published)
+	assert.Equal(t, MQTTQueueStats{Replayed: 2}, queue.stats())
+}
+
+func TestMQTTQueueDropNewest(t *testing.T) {
+	queue, err := newMQTTQueue(&Config{
+		MQTTQueuePath:       filepath.Join(t.TempDir(), "queue.json"),
+		MQTTQueueSize:       1,
+		MQTTQueueDropPolicy: MQTTDropNewest,
+	})
+	assert.NoError(t, err)
+
+	assert.NoError(t, queue.push(queuedMessage{Topic: "a"}))
+	assert.ErrorIs(t, queue.push(queuedMessage{Topic: "b"}), ErrMQTTQueueFull)
+	assert.Equal(t, "a", queue.messages[0].Topic)
+}
+
+func TestMQTTQueueFile(t *testing.T) {
+	config := &Config{MQTTQueuePath: filepath.Join(t.TempDir(), "queue.jsonl"), MQTTQueueSize: 2}
+
+	queue, err := newMQTTQueue(config)
+	assert.NoError(t, err)
+
+	lines := func() []string {
+		data, err := os.ReadFile(config.MQTTQueuePath)
+		assert.NoError(t, err)
+		return strings.Split(strings.TrimSpace(string(data)), "\n")
+	}
+
+	assert.NoError(t, queue.push(queuedMessage{Topic: "a", Payload: []byte("1")}))
+	first := lines()
+	assert.NoError(t, queue.push(queuedMessage{Topic: "b", Payload: []byte("2")}))
+
+	// pushes append to the file instead of rewriting it
+	assert.Equal(t, first[0], lines()[0])
+	assert.Len(t, lines(), 2)
+
+	// dropped messages stay with a consumed marker until size of them piled up
+	assert.NoError(t, queue.push(queuedMessage{Topic: "c", Payload: []byte("3")}))
+	assert.Len(t, lines(), 4)
+	assert.Equal(t, `{"consumed":true}`, lines()[2])
+	assert.NoError(t, queue.push(queuedMessage{Topic: "d", Payload: []byte("4")}))
+	assert.Len(t, lines(), 2)
+
+	assert.NoError(t, queue.flush(&publishClient{}))
+	data, err := os.ReadFile(config.MQTTQueuePath)
+	assert.NoError(t, err)
+	assert.Empty(t, data)
+}
+
+func TestMQTTQueuePartialFlush(t *testing.T) {
+	config := &Config{
+		MQTTQueuePath:       filepath.Join(t.TempDir(), "queue.jsonl"),
+		MQTTQueueSize:       2,
+		MQTTQueueDropPolicy: MQTTDropNewest,
+	}
+
+	queue, err := newMQTTQueue(config)
+	assert.NoError(t, err)
+
+	assert.NoError(t, queue.push(queuedMessage{Topic: "a", Payload: []byte("1")}))
+	assert.NoError(t, queue.push(queuedMessage{Topic: "b", Payload: []byte("2")}))
+
+	// the connection drops after the first replayed message
+	client := &publishClient{limit: 1}
+	assert.Error(t, queue.flush(client))
+	assert.NoError(t, queue.push(queuedMessage{Topic: "c", Payload: []byte("3")}))
+
+	// a restart neither replays the acknowledged message nor lets it take the place of c
+	queue, err = newMQTTQueue(config)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, queue.depth())
+
+	client.limit = 0
+	assert.NoError(t, queue.flush(client))
+	assert.Equal(t, []string{"a 1", "b 2", "c 3"}, client.published)
+}
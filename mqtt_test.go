@@ -0,0 +1,257 @@
+package ucodesdk
+
+import (
+	"context"
+	"errors"
+	"net"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMQTTClientOptions(t *testing.T) {
+	opts, err := mqttClientOptions(&Config{MQTTBroker: "tcp://localhost:1883", MQTTClientID: "worker-1"})
+	assert.NoError(t, err)
+	assert.Equal(t, "worker-1", opts.ClientID)
+	assert.Equal(t, int64(30), opts.KeepAlive)
+	assert.Equal(t, time.Minute, opts.MaxReconnectInterval)
+	assert.True(t, opts.CleanSession)
+	assert.True(t, opts.AutoReconnect)
+	assert.Nil(t, opts.TLSConfig)
+
+	_, err = mqttClientOptions(&Config{MQTTCAFile: filepath.Join(t.TempDir(), "missing.pem")})
+	assert.Error(t, err)
+
+	_, err = New(&Config{}).ConnectToMQTT()
+	assert.ErrorIs(t, err, ErrMQTTNotConfigured)
+}
+
+func TestMQTTManagerDispatch(t *testing.T) {
+	var (
+		manager = newMQTTManager(&Config{})
+		calls   []string
+	)
+
+	sub := &mqttSubscription{qos: 1, handlers: map[uint64]mqtt.MessageHandler{
+		1: func(mqtt.Client, mqtt.Message) { calls = append(calls, "first") },
+		2: func(mqtt.Client, mqtt.Message) { calls = append(calls, "second") },
+	}, subscribed: &mqttAttempt{done: make(chan struct{})}}
+	close(sub.subscribed.done)
+	manager.subs["orders/+"] = sub
+
+	manager.dispatch("orders/+")(nil, testMessage{topic: "orders/1"})
+	assert.ElementsMatch(t, []string{"first", "second"}, calls)
+
+	client := &subscribeClient{}
+	assert.NoError(t, manager.unsubscribe(client, "orders/+", sub, 1))
+	assert.Len(t, manager.subs, 1)
+	assert.NoError(t, manager.unsubscribe(client, "orders/+", sub, 2))
+	assert.Empty(t, manager.subs)
+}
+
+// pendingToken is an mqtt.Token completed by the test
+type pendingToken struct {
+	done chan struct{}
+	err  error
+}
+
+func (t *pendingToken) Wait() bool                     { <-t.done; return true }
+func (t *pendingToken) WaitTimeout(time.Duration) bool { <-t.done; return true }
+func (t *pendingToken) Done() <-chan struct{}          { return t.done }
+func (t *pendingToken) Error() error                   { return t.err }
+
+// subscribeClient hands every Subscribe token to the test, and every
+// Unsubscribe token too if unsubs is set
+type subscribeClient struct {
+	mqtt.Client
+	tokens chan *pendingToken
+	unsubs chan *pendingToken
+}
+
+func (c *subscribeClient) IsConnected() bool { return true }
+
+func (c *subscribeClient) Subscribe(string, byte, mqtt.MessageHandler) mqtt.Token {
+	token := &pendingToken{done: make(chan struct{})}
+	c.tokens <- token
+	return token
+}
+
+func (c *subscribeClient) Unsubscribe(...string) mqtt.Token {
+	if c.unsubs == nil {
+		return newDoneToken(nil)
+	}
+	token := &pendingToken{done: make(chan struct{})}
+	c.unsubs <- token
+	return token
+}
+
+func TestMQTTManagerSubscribeRace(t *testing.T) {
+	var (
+		client  = &subscribeClient{tokens: make(chan *pendingToken, 1)}
+		manager = newMQTTManager(&Config{})
+		errs    = make(chan error, 2)
+		noop    = func(mqtt.Client, mqtt.Message) {}
+	)
+	manager.client = client
+
+	subscribe := func() {
+		_, err := manager.subscribe(context.Background(), "orders/+", 1, noop)
+		errs <- err
+	}
+
+	go subscribe()
+	token := <-client.tokens
+
+	// the second handler joins the pending broker subscribe instead of starting one
+	go subscribe()
+	assert.Eventually(t, func() bool {
+		manager.mu.Lock()
+		defer manager.mu.Unlock()
+		return len(manager.subs["orders/+"].handlers) == 2
+	}, time.Second, time.Millisecond)
+
+	token.err = errors.New("not authorized")
+	close(token.done)
+
+	assert.ErrorContains(t, <-errs, "not authorized")
+	assert.ErrorContains(t, <-errs, "not authorized")
+	assert.Empty(t, manager.subs)
+
+	// the next subscribe tries again
+	go subscribe()
+	close((<-client.tokens).done)
+	assert.NoError(t, <-errs)
+	assert.Len(t, manager.subs["orders/+"].handlers, 1)
+}
+
+func TestMQTTManagerSubscribeWhileUnsubscribing(t *testing.T) {
+	var (
+		client  = &subscribeClient{tokens: make(chan *pendingToken, 1), unsubs: make(chan *pendingToken, 1)}
+		manager = newMQTTManager(&Config{})
+		errs    = make(chan error, 2)
+		noop    = func(mqtt.Client, mqtt.Message) {}
+	)
+	manager.client = client
+
+	go func() {
+		unsubscribe, err := manager.subscribe(context.Background(), "orders/+", 1, noop)
+		errs <- err
+		errs <- unsubscribe()
+	}()
+	close((<-client.tokens).done)
+	assert.NoError(t, <-errs)
+	unsubscribing := <-client.unsubs
+
+	// a subscribe during the broker unsubscribe waits for it instead of joining the old subscription
+	go func() {
+		_, err := manager.subscribe(context.Background(), "orders/+", 1, noop)
+		errs <- err
+	}()
+	assert.Never(t, func() bool { return len(client.tokens) > 0 }, 50*time.Millisecond, time.Millisecond)
+
+	close(unsubscribing.done)
+	assert.NoError(t, <-errs)
+
+	close((<-client.tokens).done)
+	assert.NoError(t, <-errs)
+	assert.Len(t, manager.subs["orders/+"].handlers, 1)
+	assert.Nil(t, manager.subs["orders/+"].closing)
+}
+
+func TestMQTTManagerSubscribeCanceled(t *testing.T) {
+	var (
+		client      = &subscribeClient{tokens: make(chan *pendingToken, 1), unsubs: make(chan *pendingToken, 1)}
+		manager     = newMQTTManager(&Config{})
+		ctx, cancel = context.WithCancel(context.Background())
+		errs        = make(chan error, 1)
+	)
+	manager.client = client
+
+	go func() {
+		_, err := manager.subscribe(ctx, "orders/+", 1, func(mqtt.Client, mqtt.Message) {})
+		errs <- err
+	}()
+	token := <-client.tokens
+
+	cancel()
+	assert.ErrorIs(t, <-errs, context.Canceled)
+
+	// the subscribe that completes after the cancel is undone at the broker
+	close(token.done)
+	close((<-client.unsubs).done)
+	assert.Eventually(t, func() bool {
+		manager.mu.Lock()
+		defer manager.mu.Unlock()
+		return len(manager.subs) == 0
+	}, time.Second, time.Millisecond)
+}
+
+// silentBroker accepts MQTT connections and never answers them
+func silentBroker(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		conns []net.Conn
+		mu    sync.Mutex
+	)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			conns = append(conns, conn)
+			mu.Unlock()
+		}
+	}()
+
+	t.Cleanup(func() {
+		listener.Close()
+		mu.Lock()
+		for _, conn := range conns {
+			conn.Close()
+		}
+		mu.Unlock()
+	})
+
+	return "tcp://" + listener.Addr().String()
+}
+
+func TestMQTTManagerConnectContext(t *testing.T) {
+	manager := newMQTTManager(&Config{MQTTBroker: silentBroker(t)})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := manager.connect(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// the pending connect doesn't hold the manager lock
+	assert.True(t, manager.mu.TryLock())
+	assert.NotNil(t, manager.connecting)
+	manager.mu.Unlock()
+}
+
+func TestMQTTManagerPublishQueuesWhileConnecting(t *testing.T) {
+	manager := newMQTTManager(&Config{
+		MQTTBroker:    silentBroker(t),
+		MQTTQueuePath: filepath.Join(t.TempDir(), "queue.jsonl"),
+	})
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, manager.publish(context.Background(), "orders/1", 1, false, []byte("paid")))
+	}
+
+	// publishes don't wait for the connect, onConnect replays them
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, 3, manager.queue.depth())
+}
@@ -0,0 +1,358 @@
+// Package mqttbridge writes MQTT messages into Items collections.
+//
+// Routes map topic patterns to collections, fields of the JSON payload and
+// topic levels are mapped to collection fields with templates, and rows are
+// inserted in batches. Messages that can't be decoded, fail validation or
+// whose insert fails are dead-lettered:
+//
+//	err := mqttbridge.New(ucode).
+//		Route(mqttbridge.Route{
+//			Topic:      "devices/+/telemetry",
+//			Collection: "telemetry",
+//			Fields: map[string]string{
+//				"device_id":   "{{topic.1}}",
+//				"temperature": "{{payload.temp}}",
+//				"label":       "{{topic.1}}: {{payload.temp}}C",
+//				"received_at": "{{received_at}}",
+//			},
+//			Required: []string{"temperature"},
+//		}).
+//		BatchSize(100).
+//		FlushInterval(time.Second).
+//		DeadLetterTopic("bridge/dead").
+//		Run(ctx)
+package mqttbridge
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	sdk "github.com/ucode-io/ucode_sdk"
+)
+
+type Route struct {
+	// Topic may contain + and # wildcards
+	Topic      string
+	Collection string
+	// Fields maps collection fields to templates, see the placeholders of compileTemplate:
+	// {{payload.path}}, {{topic}}, {{topic.N}} and {{received_at}}
+	Fields map[string]string
+	// Required fields must have a non-empty value after mapping
+	Required []string
+	// Validate checks the mapped row before it's inserted
+	Validate func(row map[string]any) error
+}
+
+// DeadLetter is a message that wasn't written to its collection
+type DeadLetter struct {
+	Topic      string    `json:"topic"`
+	Payload    string    `json:"payload"`
+	Collection string    `json:"collection"`
+	Reason     string    `json:"reason"`
+	ReceivedAt time.Time `json:"received_at"`
+}
+
+type Stats struct {
+	Received     uint64
+	Inserted     uint64
+	DeadLettered uint64
+	// DeadLetterPublishFailed counts dead letters that couldn't be published to DeadLetterTopic
+	DeadLetterPublishFailed uint64
+}
+
+// deadLetterTimeout limits the publish of a dead letter, so letters don't pile up while the broker is down
+const deadLetterTimeout = 10 * time.Second
+
+type Bridge struct {
+	ucode           sdk.UcodeApis
+	routes          []Route
+	batchSize       int
+	flushInterval   time.Duration
+	qos             byte
+	deadLetterTopic string
+	onDeadLetter    func(DeadLetter)
+
+	mu      sync.Mutex
+	batches map[string][]pending
+	stats   Stats
+}
+
+type pending struct {
+	row    map[string]any
+	letter DeadLetter
+}
+
+func New(ucode sdk.UcodeApis) *Bridge {
+	return &Bridge{
+		ucode:         ucode,
+		batchSize:     100,
+		flushInterval: time.Second,
+		qos:           1,
+		batches:       map[string][]pending{},
+	}
+}
+
+func (b *Bridge) Route(route Route) *Bridge {
+	b.routes = append(b.routes, route)
+	return b
+}
+
+// BatchSize is the number of rows inserted with one request, default 100
+func (b *Bridge) BatchSize(size int) *Bridge {
+	if size > 0 {
+		b.batchSize = size
+	}
+	return b
+}
+
+// FlushInterval is the longest time a row waits for its batch to fill, default 1s
+func (b *Bridge) FlushInterval(interval time.Duration) *Bridge {
+	if interval > 0 {
+		b.flushInterval = interval
+	}
+	return b
+}
+
+func (b *Bridge) QoS(qos byte) *Bridge {
+	b.qos = qos
+	return b
+}
+
+// DeadLetterTopic publishes dead letters as JSON to topic
+func (b *Bridge) DeadLetterTopic(topic string) *Bridge {
+	b.deadLetterTopic = topic
+	return b
+}
+
+// OnDeadLetter calls handler for every dead letter
+func (b *Bridge) OnDeadLetter(handler func(DeadLetter)) *Bridge {
+	b.onDeadLetter = handler
+	return b
+}
+
+func (b *Bridge) Stats() Stats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.stats
+}
+
+/*
+Run subscribes to the route topics and writes messages until ctx is done.
+Rows still waiting in batches are inserted before Run returns.
+*/
+func (b *Bridge) Run(ctx context.Context) error {
+	if len(b.routes) == 0 {
+		return errors.New("mqttbridge: no routes")
+	}
+
+	mappings := make([]map[string]template, len(b.routes))
+	for i, route := range b.routes {
+		if route.Topic == "" || route.Collection == "" {
+			return fmt.Errorf("mqttbridge: route %d has no topic or collection", i)
+		}
+
+		mappings[i] = map[string]template{}
+		for field, value := range route.Fields {
+			t, err := compileTemplate(value)
+			if err != nil {
+				return fmt.Errorf("mqttbridge: field %s of %s: %w", field, route.Collection, err)
+			}
+			mappings[i][field] = t
+		}
+	}
+
+	var (
+		pubsub = b.ucode.PubSub().Codec(rawCodec{}).QoS(b.qos)
+		full   = make(chan string, len(b.routes))
+		subs   []*sdk.Subscription
+	)
+
+	defer func() {
+		for _, sub := range subs {
+			sub.Close()
+		}
+		b.flushAll(context.WithoutCancel(ctx))
+	}()
+
+	for i, route := range b.routes {
+		route, mapping := route, mappings[i]
+
+		sub, err := sdk.SubscribeMessage(ctx, pubsub, route.Topic, func(msg sdk.Message[[]byte]) {
+			if b.receive(ctx, route, mapping, msg) {
+				select {
+				case full <- route.Collection:
+				default:
+				}
+			}
+		})
+		if err != nil {
+			return fmt.Errorf("mqttbridge: %w", err)
+		}
+		subs = append(subs, sub)
+	}
+
+	ticker := time.NewTicker(b.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case collection := <-full:
+			b.flush(ctx, collection)
+		case <-ticker.C:
+			b.flushAll(ctx)
+		}
+	}
+}
+
+// receive maps a message and adds it to the batch of its collection, it reports whether the batch is full
+func (b *Bridge) receive(ctx context.Context, route Route, mapping map[string]template, msg sdk.Message[[]byte]) bool {
+	letter := DeadLetter{
+		Topic:      msg.Topic,
+		Payload:    string(msg.Payload),
+		Collection: route.Collection,
+		ReceivedAt: time.Now(),
+	}
+
+	b.mu.Lock()
+	b.stats.Received++
+	b.mu.Unlock()
+
+	var payload map[string]any
+	if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+		b.deadLetter(ctx, letter, fmt.Errorf("decoding payload: %w", err))
+		return false
+	}
+
+	row := map[string]any{}
+	for field, t := range mapping {
+		if value := t.render(message{topic: msg.Topic, payload: payload, receivedAt: letter.ReceivedAt}); value != nil {
+			row[field] = value
+		}
+	}
+
+	for _, field := range route.Required {
+		if value, ok := row[field]; !ok || value == "" {
+			b.deadLetter(ctx, letter, fmt.Errorf("required field %s is empty", field))
+			return false
+		}
+	}
+
+	if route.Validate != nil {
+		if err := route.Validate(row); err != nil {
+			b.deadLetter(ctx, letter, err)
+			return false
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.batches[route.Collection] = append(b.batches[route.Collection], pending{row: row, letter: letter})
+	return len(b.batches[route.Collection]) >= b.batchSize
+}
+
+func (b *Bridge) flushAll(ctx context.Context) {
+	b.mu.Lock()
+	collections := make([]string, 0, len(b.batches))
+	for collection := range b.batches {
+		collections = append(collections, collection)
+	}
+	b.mu.Unlock()
+
+	for _, collection := range collections {
+		b.flush(ctx, collection)
+	}
+}
+
+// flush inserts the rows of collection in batches of BatchSize with one PATCH
+// request each, rows of a failed or rejected request are dead-lettered
+func (b *Bridge) flush(ctx context.Context, collection string) {
+	for {
+		b.mu.Lock()
+		batch := b.batches[collection]
+		if len(batch) > b.batchSize {
+			batch = batch[:b.batchSize]
+		}
+		b.batches[collection] = b.batches[collection][len(batch):]
+		if len(b.batches[collection]) == 0 {
+			delete(b.batches, collection)
+		}
+		b.mu.Unlock()
+
+		if len(batch) == 0 {
+			return
+		}
+
+		objects := make([]map[string]any, 0, len(batch))
+		for _, p := range batch {
+			object := make(map[string]any, len(p.row)+1)
+			for field, value := range p.row {
+				object[field] = value
+			}
+			object["is_new"] = true
+			objects = append(objects, object)
+		}
+
+		_, _, err := b.ucode.Items(collection).Update(map[string]any{"objects": objects}).CheckStatus(true).Context(ctx).ExecMultiple()
+		if err != nil {
+			for _, p := range batch {
+				b.deadLetter(ctx, p.letter, fmt.Errorf("inserting into %s: %w", collection, err))
+			}
+			continue
+		}
+
+		b.mu.Lock()
+		b.stats.Inserted += uint64(len(batch))
+		b.mu.Unlock()
+	}
+}
+
+func (b *Bridge) deadLetter(ctx context.Context, letter DeadLetter, reason error) {
+	letter.Reason = reason.Error()
+
+	b.mu.Lock()
+	b.stats.DeadLettered++
+	b.mu.Unlock()
+
+	if b.onDeadLetter != nil {
+		b.onDeadLetter(letter)
+	}
+
+	if b.deadLetterTopic != "" {
+		// the letter is published from the subscription callback, don't wait for the broker there
+		go b.publishDeadLetter(context.WithoutCancel(ctx), letter)
+	}
+}
+
+func (b *Bridge) publishDeadLetter(ctx context.Context, letter DeadLetter) {
+	ctx, cancel := context.WithTimeout(ctx, deadLetterTimeout)
+	defer cancel()
+
+	if err := b.ucode.PubSub().Publish(ctx, b.deadLetterTopic, letter, b.qos); err != nil {
+		b.mu.Lock()
+		b.stats.DeadLetterPublishFailed++
+		b.mu.Unlock()
+	}
+}
+
+// rawCodec passes payloads through undecoded, the bridge decodes them itself to dead-letter invalid ones
+type rawCodec struct{}
+
+func (rawCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (rawCodec) Unmarshal(data []byte, v any) error {
+	p, ok := v.(*[]byte)
+	if !ok {
+		return fmt.Errorf("raw codec can't decode into %T", v)
+	}
+	*p = append([]byte(nil), data...)
+	return nil
+}
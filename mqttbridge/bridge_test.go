@@ -0,0 +1,181 @@
+package mqttbridge
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdk "github.com/ucode-io/ucode_sdk"
+	"github.com/ucode-io/ucode_sdk/mqtttest"
+	"github.com/ucode-io/ucode_sdk/ucodetest"
+)
+
+func TestTemplate(t *testing.T) {
+	msg := message{
+		topic:      "devices/42/telemetry",
+		payload:    map[string]any{"temp": 21.5, "meta": map[string]any{"unit": "C"}},
+		receivedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+
+	cases := map[string]any{
+		"{{payload.temp}}":        21.5,
+		"{{ payload.meta.unit }}": "C",
+		"{{topic.1}}":             "42",
+		"{{topic}}":               "devices/42/telemetry",
+		"{{received_at}}":         "2024-01-02T03:04:05Z",
+		"{{topic.1}}: {{payload.temp}}{{payload.meta.unit}}": "42: 21.5C",
+		"{{payload.missing}}":                                nil,
+		"{{topic.9}}":                                        nil,
+		"sensor":                                             "sensor",
+	}
+
+	for s, expected := range cases {
+		tmpl, err := compileTemplate(s)
+		require.NoError(t, err, s)
+		assert.Equal(t, expected, tmpl.render(msg), s)
+	}
+
+	for _, s := range []string{"{{payload.temp", "{{unknown}}", "{{topic.x}}"} {
+		_, err := compileTemplate(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestBridge(t *testing.T) {
+	var (
+		api    = ucodetest.NewServer()
+		broker = mqtttest.NewBroker()
+	)
+	defer api.Close()
+	defer broker.Close()
+
+	config := api.Config()
+	config.MQTTBroker = broker.URL
+
+	var (
+		mu      sync.Mutex
+		letters []DeadLetter
+	)
+
+	bridge := New(sdk.New(config)).
+		Route(Route{
+			Topic:      "devices/+/telemetry",
+			Collection: "telemetry",
+			Fields: map[string]string{
+				"device_id":   "{{topic.1}}",
+				"temperature": "{{payload.temp}}",
+			},
+			Required: []string{"temperature"},
+			Validate: func(row map[string]any) error {
+				if row["temperature"].(float64) > 100 {
+					return errors.New("temperature out of range")
+				}
+				return nil
+			},
+		}).
+		BatchSize(2).
+		FlushInterval(50 * time.Millisecond).
+		OnDeadLetter(func(letter DeadLetter) {
+			mu.Lock()
+			letters = append(letters, letter)
+			mu.Unlock()
+		})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- bridge.Run(ctx) }()
+
+	require.True(t, broker.WaitSubscribed("devices/+/telemetry", 2*time.Second))
+
+	broker.Publish("devices/1/telemetry", []byte(`{"temp": 20}`), 1, false)
+	broker.Publish("devices/2/telemetry", []byte(`{"temp": 21}`), 1, false)
+	broker.Publish("devices/3/telemetry", []byte(`{"temp": 22}`), 1, false)
+	broker.Publish("devices/4/telemetry", []byte(`not json`), 1, false)
+	broker.Publish("devices/5/telemetry", []byte(`{"humidity": 40}`), 1, false)
+	broker.Publish("devices/6/telemetry", []byte(`{"temp": 500}`), 1, false)
+
+	require.Eventually(t, func() bool {
+		stats := bridge.Stats()
+		return stats.Inserted == 3 && stats.DeadLettered == 3
+	}, 2*time.Second, 10*time.Millisecond)
+
+	cancel()
+	assert.NoError(t, <-done)
+
+	var devices []string
+	for _, item := range api.Items("telemetry") {
+		devices = append(devices, item["device_id"].(string))
+	}
+	sort.Strings(devices)
+	assert.Equal(t, []string{"1", "2", "3"}, devices)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, letters, 3)
+	sort.Slice(letters, func(i, j int) bool { return letters[i].Topic < letters[j].Topic })
+	assert.Contains(t, letters[0].Reason, "decoding payload")
+	assert.Equal(t, "required field temperature is empty", letters[1].Reason)
+	assert.Equal(t, "temperature out of range", letters[2].Reason)
+}
+
+func TestBridgeRejectedInsert(t *testing.T) {
+	var (
+		api    = ucodetest.NewServer()
+		broker = mqtttest.NewBroker()
+	)
+	defer api.Close()
+	defer broker.Close()
+
+	api.RejectWrites("telemetry", http.StatusInternalServerError)
+
+	config := api.Config()
+	config.MQTTBroker = broker.URL
+
+	letters := make(chan DeadLetter, 1)
+
+	bridge := New(sdk.New(config)).
+		Route(Route{
+			Topic:      "devices/+/telemetry",
+			Collection: "telemetry",
+			Fields:     map[string]string{"temperature": "{{payload.temp}}"},
+		}).
+		BatchSize(1).
+		OnDeadLetter(func(letter DeadLetter) { letters <- letter })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- bridge.Run(ctx) }()
+
+	require.True(t, broker.WaitSubscribed("devices/+/telemetry", 2*time.Second))
+	broker.Publish("devices/1/telemetry", []byte(`{"temp": 20}`), 1, false)
+
+	select {
+	case letter := <-letters:
+		assert.Equal(t, "devices/1/telemetry", letter.Topic)
+		assert.Contains(t, letter.Reason, "inserting into telemetry: server responded with status 500")
+	case <-time.After(2 * time.Second):
+		t.Fatal("rejected insert was not dead-lettered")
+	}
+
+	cancel()
+	assert.NoError(t, <-done)
+
+	assert.Equal(t, Stats{Received: 1, DeadLettered: 1}, bridge.Stats())
+}
+
+func TestBridgeDeadLetterPublishFailed(t *testing.T) {
+	// without a broker the publish fails instead of waiting
+	bridge := New(sdk.New(&sdk.Config{})).DeadLetterTopic("bridge/dead")
+
+	bridge.deadLetter(context.Background(), DeadLetter{Topic: "devices/1/telemetry"}, errors.New("invalid"))
+
+	assert.Eventually(t, func() bool {
+		return bridge.Stats() == Stats{DeadLettered: 1, DeadLetterPublishFailed: 1}
+	}, time.Second, time.Millisecond)
+}
@@ -0,0 +1,125 @@
+package mqttbridge
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+template is a compiled field mapping. Placeholders are replaced with values of
+the message:
+
+	{{payload.sensor.temperature}}  field of the JSON payload, nested with dots
+	{{topic}}                       the whole topic
+	{{topic.1}}                     topic level, counted from 0
+	{{received_at}}                 time the message was received, RFC 3339
+
+A template made of a single placeholder keeps the type of the value, otherwise
+the values are formatted into a string. Text without placeholders is a constant.
+*/
+type template struct {
+	parts []templatePart
+}
+
+type templatePart struct {
+	text string
+	expr []string // nil for text
+}
+
+type message struct {
+	topic      string
+	payload    map[string]any
+	receivedAt time.Time
+}
+
+func compileTemplate(s string) (template, error) {
+	var t template
+
+	for len(s) > 0 {
+		start := strings.Index(s, "{{")
+		if start < 0 {
+			t.parts = append(t.parts, templatePart{text: s})
+			break
+		}
+
+		end := strings.Index(s[start:], "}}")
+		if end < 0 {
+			return template{}, fmt.Errorf("unclosed placeholder in %q", s)
+		}
+		end += start
+
+		if start > 0 {
+			t.parts = append(t.parts, templatePart{text: s[:start]})
+		}
+
+		expr := strings.Split(strings.TrimSpace(s[start+2:end]), ".")
+		switch expr[0] {
+		case "payload", "topic", "received_at":
+		default:
+			return template{}, fmt.Errorf("unknown placeholder {{%s}}", strings.Join(expr, "."))
+		}
+		if expr[0] == "topic" && len(expr) == 2 {
+			if _, err := strconv.Atoi(expr[1]); err != nil {
+				return template{}, fmt.Errorf("topic level must be a number in {{%s}}", strings.Join(expr, "."))
+			}
+		}
+		t.parts = append(t.parts, templatePart{expr: expr})
+
+		s = s[end+2:]
+	}
+
+	return t, nil
+}
+
+// render returns the mapped value, nil if a single placeholder has no value
+func (t template) render(msg message) any {
+	if len(t.parts) == 1 {
+		if t.parts[0].expr == nil {
+			return t.parts[0].text
+		}
+		return msg.lookup(t.parts[0].expr)
+	}
+
+	var b strings.Builder
+	for _, part := range t.parts {
+		if part.expr == nil {
+			b.WriteString(part.text)
+			continue
+		}
+		if value := msg.lookup(part.expr); value != nil {
+			fmt.Fprint(&b, value)
+		}
+	}
+
+	return b.String()
+}
+
+func (m message) lookup(expr []string) any {
+	switch expr[0] {
+	case "received_at":
+		return m.receivedAt.Format(time.RFC3339)
+	case "topic":
+		if len(expr) == 1 {
+			return m.topic
+		}
+		levels := strings.Split(m.topic, "/")
+		index, _ := strconv.Atoi(expr[1])
+		if index < 0 || index >= len(levels) {
+			return nil
+		}
+		return levels[index]
+	}
+
+	var value any = m.payload
+	for _, key := range expr[1:] {
+		object, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		value = object[key]
+	}
+
+	return value
+}
@@ -0,0 +1,399 @@
+// Package mqtttest provides an in-process MQTT 3.1.1 broker for tests.
+//
+// It supports topics with + and # wildcards, QoS 0 and 1, retained messages
+// and will messages, enough to test code built on ConnectToMQTT, PubSub,
+// Items().Watch and MQTTRPC without a real broker:
+//
+//	broker := mqtttest.NewBroker()
+//	defer broker.Close()
+//
+//	api := sdk.New(broker.Config())
+//	err := api.PubSub().Publish(ctx, "orders/1", order, 1)
+//
+// QoS 2 publishes are accepted and delivered with QoS 1. Sessions are not kept
+// after a client disconnects.
+package mqtttest
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/eclipse/paho.mqtt.golang/packets"
+	sdk "github.com/ucode-io/ucode_sdk"
+)
+
+type Broker struct {
+	// URL is the broker address for Config.MQTTBroker, tcp://127.0.0.1:<port>
+	URL string
+
+	listener net.Listener
+	wg       sync.WaitGroup
+
+	mu       sync.Mutex
+	clients  map[string]*client
+	retained map[string]*packets.PublishPacket
+	closed   bool
+}
+
+// NewBroker starts a broker on a local port, it panics if it can't listen like httptest.NewServer
+func NewBroker() *Broker {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(fmt.Sprintf("mqtttest: failed to listen: %v", err))
+	}
+
+	b := &Broker{
+		URL:      "tcp://" + listener.Addr().String(),
+		listener: listener,
+		clients:  map[string]*client{},
+		retained: map[string]*packets.PublishPacket{},
+	}
+
+	b.wg.Add(1)
+	go b.serve()
+
+	return b
+}
+
+// Config returns an SDK config pointing to the broker
+func (b *Broker) Config() *sdk.Config {
+	return &sdk.Config{
+		MQTTBroker: b.URL,
+		AppId:      "mqtttest",
+		ProjectId:  "mqtttest",
+	}
+}
+
+// Close disconnects all clients and stops the broker
+func (b *Broker) Close() {
+	b.mu.Lock()
+	b.closed = true
+	b.mu.Unlock()
+
+	b.listener.Close()
+	b.DisconnectAll()
+	b.wg.Wait()
+}
+
+// DisconnectAll drops the connections of all clients without a DISCONNECT, like a network failure
+func (b *Broker) DisconnectAll() {
+	b.mu.Lock()
+	clients := make([]*client, 0, len(b.clients))
+	for _, c := range b.clients {
+		clients = append(clients, c)
+	}
+	b.mu.Unlock()
+
+	for _, c := range clients {
+		c.conn.Close()
+	}
+}
+
+// Clients returns the ids of connected clients
+func (b *Broker) Clients() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ids := make([]string, 0, len(b.clients))
+	for id := range b.clients {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// Publish delivers payload to subscribers of topic as if a client published it
+func (b *Broker) Publish(topic string, payload []byte, qos byte, retain bool) {
+	pub := packets.NewControlPacket(packets.Publish).(*packets.PublishPacket)
+	pub.TopicName = topic
+	pub.Payload = payload
+	pub.Qos = qos
+	pub.Retain = retain
+
+	b.route(pub)
+}
+
+// Retained returns the retained message of topic
+func (b *Broker) Retained(topic string) ([]byte, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	pub, ok := b.retained[topic]
+	if !ok {
+		return nil, false
+	}
+	return pub.Payload, true
+}
+
+// WaitSubscribed waits until some client subscribed to filter, use it before publishing
+// to avoid racing with asynchronous subscriptions
+func (b *Broker) WaitSubscribed(filter string, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+
+	for time.Now().Before(deadline) {
+		b.mu.Lock()
+		for _, c := range b.clients {
+			if _, ok := c.subscriptions()[filter]; ok {
+				b.mu.Unlock()
+				return true
+			}
+		}
+		b.mu.Unlock()
+
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	return false
+}
+
+func (b *Broker) serve() {
+	defer b.wg.Done()
+
+	for {
+		conn, err := b.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		b.wg.Add(1)
+		go func() {
+			defer b.wg.Done()
+			b.handle(conn)
+		}()
+	}
+}
+
+func (b *Broker) handle(conn net.Conn) {
+	defer conn.Close()
+
+	packet, err := packets.ReadPacket(conn)
+	if err != nil {
+		return
+	}
+
+	connect, ok := packet.(*packets.ConnectPacket)
+	if !ok {
+		return
+	}
+
+	connack := packets.NewControlPacket(packets.Connack).(*packets.ConnackPacket)
+	if connect.ProtocolName != "MQTT" || connect.ProtocolVersion != 4 {
+		connack.ReturnCode = packets.ErrRefusedBadProtocolVersion
+		connack.Write(conn)
+		return
+	}
+
+	c := &client{
+		id:   connect.ClientIdentifier,
+		conn: conn,
+		subs: map[string]byte{},
+	}
+	if c.id == "" {
+		c.id = fmt.Sprintf("mqtttest-%p", c)
+	}
+	if connect.WillFlag {
+		c.will = packets.NewControlPacket(packets.Publish).(*packets.PublishPacket)
+		c.will.TopicName = connect.WillTopic
+		c.will.Payload = connect.WillMessage
+		c.will.Qos = connect.WillQos
+		c.will.Retain = connect.WillRetain
+	}
+
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return
+	}
+	// a second connection with the same client id takes over the session
+	if previous, ok := b.clients[c.id]; ok {
+		previous.conn.Close()
+	}
+	b.clients[c.id] = c
+	b.mu.Unlock()
+
+	defer func() {
+		b.mu.Lock()
+		if b.clients[c.id] == c {
+			delete(b.clients, c.id)
+		}
+		b.mu.Unlock()
+
+		if c.will != nil {
+			b.route(c.will)
+		}
+	}()
+
+	if err := c.write(connack); err != nil {
+		return
+	}
+
+	for {
+		packet, err := packets.ReadPacket(conn)
+		if err != nil {
+			return
+		}
+
+		switch p := packet.(type) {
+		case *packets.PublishPacket:
+			switch p.Qos {
+			case 1:
+				ack := packets.NewControlPacket(packets.Puback).(*packets.PubackPacket)
+				ack.MessageID = p.MessageID
+				c.write(ack)
+			case 2:
+				rec := packets.NewControlPacket(packets.Pubrec).(*packets.PubrecPacket)
+				rec.MessageID = p.MessageID
+				c.write(rec)
+			}
+			b.route(p)
+
+		case *packets.PubrelPacket:
+			comp := packets.NewControlPacket(packets.Pubcomp).(*packets.PubcompPacket)
+			comp.MessageID = p.MessageID
+			c.write(comp)
+
+		case *packets.SubscribePacket:
+			b.subscribe(c, p)
+
+		case *packets.UnsubscribePacket:
+			c.mu.Lock()
+			for _, topic := range p.Topics {
+				delete(c.subs, topic)
+			}
+			c.mu.Unlock()
+
+			ack := packets.NewControlPacket(packets.Unsuback).(*packets.UnsubackPacket)
+			ack.MessageID = p.MessageID
+			c.write(ack)
+
+		case *packets.PingreqPacket:
+			c.write(packets.NewControlPacket(packets.Pingresp))
+
+		case *packets.DisconnectPacket:
+			c.will = nil
+			return
+		}
+	}
+}
+
+func (b *Broker) subscribe(c *client, p *packets.SubscribePacket) {
+	ack := packets.NewControlPacket(packets.Suback).(*packets.SubackPacket)
+	ack.MessageID = p.MessageID
+
+	c.mu.Lock()
+	for i, topic := range p.Topics {
+		qos := min(p.Qoss[i], 1)
+		c.subs[topic] = qos
+		ack.ReturnCodes = append(ack.ReturnCodes, qos)
+	}
+	c.mu.Unlock()
+
+	if err := c.write(ack); err != nil {
+		return
+	}
+
+	b.mu.Lock()
+	var retained []*packets.PublishPacket
+	for topic, pub := range b.retained {
+		for _, filter := range p.Topics {
+			if sdk.TopicMatches(filter, topic) {
+				retained = append(retained, pub)
+				break
+			}
+		}
+	}
+	b.mu.Unlock()
+
+	for _, pub := range retained {
+		c.deliver(pub, true)
+	}
+}
+
+func (b *Broker) route(p *packets.PublishPacket) {
+	b.mu.Lock()
+	if p.Retain {
+		if len(p.Payload) == 0 {
+			delete(b.retained, p.TopicName)
+		} else {
+			b.retained[p.TopicName] = p
+		}
+	}
+
+	clients := make([]*client, 0, len(b.clients))
+	for _, c := range b.clients {
+		clients = append(clients, c)
+	}
+	b.mu.Unlock()
+
+	for _, c := range clients {
+		c.deliver(p, false)
+	}
+}
+
+type client struct {
+	id   string
+	conn net.Conn
+	will *packets.PublishPacket
+
+	mu     sync.Mutex
+	subs   map[string]byte
+	nextID uint16
+
+	writeMu sync.Mutex
+}
+
+func (c *client) subscriptions() map[string]byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	subs := make(map[string]byte, len(c.subs))
+	for topic, qos := range c.subs {
+		subs[topic] = qos
+	}
+	return subs
+}
+
+// deliver sends p once with the highest QoS of the matching subscriptions
+func (c *client) deliver(p *packets.PublishPacket, retained bool) {
+	c.mu.Lock()
+	matched := false
+	var qos byte
+	for filter, subQoS := range c.subs {
+		if sdk.TopicMatches(filter, p.TopicName) {
+			matched = true
+			qos = max(qos, min(subQoS, p.Qos))
+		}
+	}
+
+	var id uint16
+	if matched && qos > 0 {
+		c.nextID++
+		if c.nextID == 0 {
+			c.nextID = 1
+		}
+		id = c.nextID
+	}
+	c.mu.Unlock()
+
+	if !matched {
+		return
+	}
+
+	pub := packets.NewControlPacket(packets.Publish).(*packets.PublishPacket)
+	pub.TopicName = p.TopicName
+	pub.Payload = p.Payload
+	pub.Qos = qos
+	pub.Retain = retained
+	pub.MessageID = id
+
+	c.write(pub)
+}
+
+func (c *client) write(p packets.ControlPacket) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	c.conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+	return p.Write(c.conn)
+}
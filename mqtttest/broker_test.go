@@ -0,0 +1,198 @@
+package mqtttest
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdk "github.com/ucode-io/ucode_sdk"
+)
+
+func connect(t *testing.T, broker *Broker, id string) mqtt.Client {
+	opts := mqtt.NewClientOptions().AddBroker(broker.URL).SetClientID(id)
+	client := mqtt.NewClient(opts)
+
+	token := client.Connect()
+	require.True(t, token.WaitTimeout(time.Second))
+	require.NoError(t, token.Error())
+	t.Cleanup(func() { client.Disconnect(100) })
+
+	return client
+}
+
+func receive(t *testing.T, ch <-chan mqtt.Message) mqtt.Message {
+	select {
+	case msg := <-ch:
+		return msg
+	case <-time.After(2 * time.Second):
+		t.Fatal("message not received")
+		return nil
+	}
+}
+
+func TestBrokerPublishSubscribe(t *testing.T) {
+	broker := NewBroker()
+	defer broker.Close()
+
+	var (
+		publisher  = connect(t, broker, "publisher")
+		subscriber = connect(t, broker, "subscriber")
+		messages   = make(chan mqtt.Message, 10)
+	)
+
+	token := subscriber.Subscribe("devices/+/telemetry", 1, func(_ mqtt.Client, msg mqtt.Message) {
+		messages <- msg
+	})
+	require.True(t, token.WaitTimeout(time.Second))
+	require.NoError(t, token.Error())
+
+	require.NoError(t, waitPublish(publisher.Publish("devices/1/status", 1, false, "skip")))
+	require.NoError(t, waitPublish(publisher.Publish("devices/1/telemetry", 1, false, "21.5")))
+
+	msg := receive(t, messages)
+	assert.Equal(t, "devices/1/telemetry", msg.Topic())
+	assert.Equal(t, "21.5", string(msg.Payload()))
+	assert.Equal(t, byte(1), msg.Qos())
+}
+
+func TestBrokerRetained(t *testing.T) {
+	broker := NewBroker()
+	defer broker.Close()
+
+	publisher := connect(t, broker, "publisher")
+	require.NoError(t, waitPublish(publisher.Publish("config/devices", 1, true, `{"interval":5}`)))
+
+	payload, ok := broker.Retained("config/devices")
+	assert.True(t, ok)
+	assert.Equal(t, `{"interval":5}`, string(payload))
+
+	messages := make(chan mqtt.Message, 1)
+	subscriber := connect(t, broker, "subscriber")
+	subscriber.Subscribe("config/#", 0, func(_ mqtt.Client, msg mqtt.Message) {
+		messages <- msg
+	}).Wait()
+
+	msg := receive(t, messages)
+	assert.True(t, msg.Retained())
+	assert.Equal(t, `{"interval":5}`, string(msg.Payload()))
+
+	require.NoError(t, waitPublish(publisher.Publish("config/devices", 1, true, "")))
+	_, ok = broker.Retained("config/devices")
+	assert.False(t, ok)
+}
+
+func TestSDKPubSub(t *testing.T) {
+	broker := NewBroker()
+	defer broker.Close()
+
+	type reading struct {
+		Device string  `json:"device" msgpack:"device"`
+		Value  float64 `json:"value" msgpack:"value"`
+	}
+
+	var (
+		ctx      = context.Background()
+		api      = sdk.New(broker.Config())
+		pubsub   = api.PubSub().Codec(sdk.MsgpackCodec)
+		readings = make(chan sdk.Message[reading], 1)
+	)
+
+	sub, err := sdk.SubscribeMessage(ctx, pubsub, "devices/+/telemetry", func(msg sdk.Message[reading]) {
+		readings <- msg
+	})
+	require.NoError(t, err)
+	defer sub.Close()
+
+	require.NoError(t, pubsub.Publish(ctx, "devices/7/telemetry", reading{Device: "7", Value: 3.5}, 1))
+
+	select {
+	case msg := <-readings:
+		assert.Equal(t, "devices/7/telemetry", msg.Topic)
+		assert.Equal(t, reading{Device: "7", Value: 3.5}, msg.Payload)
+	case <-time.After(2 * time.Second):
+		t.Fatal("message not received")
+	}
+}
+
+func TestSDKWatchResubscribes(t *testing.T) {
+	broker := NewBroker()
+	defer broker.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	config := broker.Config()
+	config.MQTTMaxReconnectInterval = 100 * time.Millisecond
+
+	watcher, err := sdk.New(config).Items("order").Watch(ctx, map[string]any{"status": "new"}).Exec()
+	require.NoError(t, err)
+
+	topic := sdk.ItemsTopic("mqtttest", "order", "create")
+	event, _ := json.Marshal(map[string]any{"action": "create", "object_id": "1", "data": map[string]any{"status": "new"}})
+
+	broker.Publish(topic, event, 1, false)
+	assert.Equal(t, "1", (<-watcher.Events()).ObjectID)
+
+	broker.DisconnectAll()
+	require.Eventually(t, func() bool {
+		return len(broker.Clients()) == 1 && broker.WaitSubscribed(sdk.ItemsTopic("mqtttest", "order", "+"), 10*time.Millisecond)
+	}, 5*time.Second, 20*time.Millisecond)
+
+	broker.Publish(topic, event, 1, false)
+	select {
+	case e := <-watcher.Events():
+		assert.Equal(t, sdk.ItemCreated, e.Type)
+	case <-time.After(2 * time.Second):
+		t.Fatal("event not received after reconnect")
+	}
+
+	cancel()
+	<-watcher.Done()
+	_, open := <-watcher.Events()
+	assert.False(t, open)
+}
+
+func TestSDKRPC(t *testing.T) {
+	broker := NewBroker()
+	defer broker.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	server := sdk.New(broker.Config())
+	go server.MQTTRPC().
+		Handle("devices/+/double", func(ctx context.Context, req sdk.RPCRequest) (any, error) {
+			var n int
+			if err := req.Decode(&n); err != nil {
+				return nil, err
+			}
+			return n * 2, nil
+		}).
+		Serve(ctx)
+	require.True(t, broker.WaitSubscribed("devices/+/double", 2*time.Second))
+
+	client := sdk.New(broker.Config())
+
+	reply, err := client.MQTTRPC().Call(ctx, "devices/1/double", 21)
+	require.NoError(t, err)
+
+	var n int
+	assert.NoError(t, reply.Decode(&n))
+	assert.Equal(t, 42, n)
+
+	_, err = client.MQTTRPC().Call(ctx, "devices/1/double", "not a number")
+	var rpcErr *sdk.RPCError
+	assert.ErrorAs(t, err, &rpcErr)
+
+	_, err = client.MQTTRPC().Timeout(50*time.Millisecond).Call(ctx, "devices/1/unknown", 1)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func waitPublish(token mqtt.Token) error {
+	token.WaitTimeout(time.Second)
+	return token.Error()
+}
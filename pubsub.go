@@ -0,0 +1,210 @@
+package ucodesdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Codec encodes values published with PubSub and decodes received payloads
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+var (
+	JSONCodec    Codec = jsonCodec{}
+	MsgpackCodec Codec = msgpackCodec{}
+)
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v any) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) Marshal(v any) ([]byte, error) { return msgpack.Marshal(v) }
+func (msgpackCodec) Unmarshal(data []byte, v any) error {
+	return msgpack.Unmarshal(data, v)
+}
+
+func (u *object) PubSub() *PubSub {
+	return &PubSub{
+		mqtt:  u.mqtt,
+		codec: JSONCodec,
+		qos:   1,
+	}
+}
+
+// Codec returns a copy of the PubSub using codec, default JSONCodec
+func (p *PubSub) Codec(codec Codec) *PubSub {
+	copied := *p
+	copied.codec = codec
+	return &copied
+}
+
+// QoS returns a copy of the PubSub subscribing with qos, default 1
+func (p *PubSub) QoS(qos byte) *PubSub {
+	copied := *p
+	copied.qos = qos
+	return &copied
+}
+
+// Publish encodes v with the codec and publishes it, []byte is sent as is.
+// It returns when the broker acknowledged the message (qos > 0) or ctx is done,
+// or when the message is stored in the offline queue, see Config.MQTTQueuePath.
+func (p *PubSub) Publish(ctx context.Context, topic string, v any, qos byte) error {
+	payload, ok := v.([]byte)
+	if !ok {
+		var err error
+		if payload, err = p.codec.Marshal(v); err != nil {
+			return fmt.Errorf("encoding message for %s: %w", topic, err)
+		}
+	}
+
+	if err := p.mqtt.publish(ctx, topic, qos, false, payload); err != nil {
+		return fmt.Errorf("publishing to %s: %w", topic, err)
+	}
+
+	return nil
+}
+
+// QueueStats returns the state of the offline queue, see Config.MQTTQueuePath
+func (p *PubSub) QueueStats() MQTTQueueStats {
+	if p.mqtt.config.MQTTQueuePath == "" {
+		return MQTTQueueStats{}
+	}
+
+	queue, err := p.mqtt.loadQueue()
+	if err != nil || queue == nil {
+		return MQTTQueueStats{}
+	}
+	return queue.stats()
+}
+
+// Message is a decoded message delivered by SubscribeMessage
+type Message[T any] struct {
+	Topic    string
+	Payload  T
+	Retained bool
+}
+
+type Subscription struct {
+	unsubscribe func() error
+	cancel      context.CancelFunc
+	done        chan struct{}
+
+	mu  sync.Mutex
+	err error
+}
+
+// Err returns the last decoding or unsubscribe error
+func (s *Subscription) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+func (s *Subscription) setErr(err error) {
+	s.mu.Lock()
+	s.err = err
+	s.mu.Unlock()
+}
+
+// Done is closed when the subscription stops
+func (s *Subscription) Done() <-chan struct{} {
+	return s.done
+}
+
+func (s *Subscription) Close() error {
+	s.cancel()
+	<-s.done
+	return s.Err()
+}
+
+/*
+Subscribe decodes messages on topic into T and passes them to handler until ctx is done.
+topic may contain + and # wildcards.
+
+	sub, err := ucodesdk.Subscribe(ctx, sdk.PubSub(), "orders/+/paid", func(order Order) {
+		fmt.Println(order.ID)
+	})
+
+Messages that can't be decoded are skipped, the last decoding error is returned by sub.Err().
+*/
+func Subscribe[T any](ctx context.Context, p *PubSub, topic string, handler func(T)) (*Subscription, error) {
+	return SubscribeMessage(ctx, p, topic, func(msg Message[T]) {
+		handler(msg.Payload)
+	})
+}
+
+// SubscribeMessage works like Subscribe and also passes the topic of every message
+func SubscribeMessage[T any](ctx context.Context, p *PubSub, topic string, handler func(Message[T])) (*Subscription, error) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	sub := &Subscription{cancel: cancel, done: make(chan struct{})}
+
+	unsubscribe, err := p.mqtt.subscribe(ctx, topic, p.qos, func(_ mqtt.Client, msg mqtt.Message) {
+		if ctx.Err() != nil {
+			return
+		}
+
+		var payload T
+		if err := p.codec.Unmarshal(msg.Payload(), &payload); err != nil {
+			sub.setErr(fmt.Errorf("decoding message from %s: %w", msg.Topic(), err))
+			return
+		}
+
+		handler(Message[T]{Topic: msg.Topic(), Payload: payload, Retained: msg.Retained()})
+	})
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	sub.unsubscribe = unsubscribe
+
+	go func() {
+		<-ctx.Done()
+		if err := sub.unsubscribe(); err != nil {
+			sub.setErr(err)
+		}
+		close(sub.done)
+	}()
+
+	return sub, nil
+}
+
+// TopicMatches reports whether topic matches the subscription filter with + and # wildcards
+func TopicMatches(filter, topic string) bool {
+	var (
+		filterLevels = strings.Split(filter, "/")
+		topicLevels  = strings.Split(topic, "/")
+	)
+
+	// wildcards don't match topics starting with $, like $SYS
+	if strings.HasPrefix(topic, "$") && len(filter) > 0 && (filter[0] == '+' || filter[0] == '#') {
+		return false
+	}
+
+	for i, level := range filterLevels {
+		if level == "#" {
+			return i == len(filterLevels)-1
+		}
+
+		if i >= len(topicLevels) {
+			return false
+		}
+
+		if level != "+" && level != topicLevels[i] {
+			return false
+		}
+	}
+
+	return len(filterLevels) == len(topicLevels)
+}
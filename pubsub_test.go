@@ -0,0 +1,60 @@
+package ucodesdk
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTopicMatches(t *testing.T) {
+	cases := []struct {
+		filter, topic string
+		match         bool
+	}{
+		{"orders/1/paid", "orders/1/paid", true},
+		{"orders/+/paid", "orders/1/paid", true},
+		{"orders/+/paid", "orders/1/created", false},
+		{"orders/+", "orders/1/paid", false},
+		{"orders/#", "orders/1/paid", true},
+		{"orders/#", "orders", true},
+		{"#", "orders/1", true},
+		{"#", "$SYS/uptime", false},
+		{"+/uptime", "$SYS/uptime", false},
+		{"$SYS/#", "$SYS/uptime", true},
+		{"orders/+/+", "orders/1", false},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.match, TopicMatches(c.filter, c.topic), "%s %s", c.filter, c.topic)
+	}
+}
+
+func TestCodecs(t *testing.T) {
+	type order struct {
+		ID    string  `json:"id" msgpack:"id"`
+		Total float64 `json:"total" msgpack:"total"`
+	}
+
+	for _, codec := range []Codec{JSONCodec, MsgpackCodec} {
+		data, err := codec.Marshal(order{ID: "1", Total: 9.5})
+		assert.NoError(t, err)
+
+		var decoded order
+		assert.NoError(t, codec.Unmarshal(data, &decoded))
+		assert.Equal(t, order{ID: "1", Total: 9.5}, decoded)
+	}
+}
+
+func TestPubSubWithoutBroker(t *testing.T) {
+	pubsub := New(&Config{}).PubSub()
+
+	err := pubsub.Publish(context.Background(), "orders/1", map[string]any{"id": 1}, 1)
+	assert.ErrorIs(t, err, ErrMQTTNotConfigured)
+
+	_, err = Subscribe(context.Background(), pubsub, "orders/+", func(map[string]any) {})
+	assert.ErrorIs(t, err, ErrMQTTNotConfigured)
+
+	err = pubsub.Publish(context.Background(), "orders/1", make(chan int), 1)
+	assert.Error(t, err)
+}
@@ -0,0 +1,358 @@
+package ucodesdk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	nurl "net/url"
+	"strings"
+	"time"
+)
+
+// requestInfo describes the SDK operation a request belongs to, it labels logs
+type requestInfo struct {
+	// operation is named "<api>.<action>", like items.create or auth.login
+	operation  string
+	collection string
+	// page and limit of list requests
+	page  int
+	limit int
+}
+
+type httpResult struct {
+	status int
+	body   []byte
+}
+
+// StatusError is returned by builders when the server answers with a non-2xx status
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("server responded with status %d: %s", e.StatusCode, truncate(e.Body, 512))
+}
+
+func checkStatus(result httpResult) error {
+	if result.status < 200 || result.status > 299 {
+		return &StatusError{StatusCode: result.status, Body: string(result.body)}
+	}
+	return nil
+}
+
+/*
+send performs a request of an SDK operation. GET and HEAD requests failing with a network
+error, 429 or 5xx are retried up to Config.MaxRetries times, writes are sent once. Every attempt is logged
+to Config.Logger: at debug level, or at error level if it failed.
+
+With Config.TracerProvider set the request is traced by a span named after the
+operation, the span context is sent in W3C traceparent headers.
+
+Every attempt is reported to Config.Metrics.
+
+Like DoRequest it doesn't treat error statuses as errors, the caller decides.
+*/
+func send(ctx context.Context, config *Config, info requestInfo, method, url string, body []byte, headers map[string]string) (result httpResult, err error) {
+	var (
+		retries = 0
+		delay   = 100 * time.Millisecond
+		attempt int
+	)
+
+	ctx, span := startSpan(ctx, config, info, method, url)
+	defer func() {
+		endSpan(span, result, err, attempt)
+	}()
+
+	if isRetryable(method) {
+		retries = config.MaxRetries
+	}
+
+	for attempt = 1; ; attempt++ {
+		if attempt > 1 && config.Metrics != nil {
+			config.Metrics.RequestRetried(info.operation)
+		}
+
+		started := time.Now()
+		if config.Metrics != nil {
+			config.Metrics.RequestStarted(info.operation)
+		}
+
+		result, err = sendOnce(ctx, method, url, body, headers)
+
+		latency := time.Since(started)
+		if config.Metrics != nil {
+			config.Metrics.RequestFinished(info.operation, result.status, err, latency)
+		}
+		logRequest(ctx, config, info, method, url, body, headers, result, err, attempt, latency)
+
+		if attempt > retries || !shouldRetry(result, err) || ctx.Err() != nil {
+			return result, err
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return result, err
+		case <-timer.C:
+		}
+		delay *= 2
+	}
+}
+
+func sendOnce(ctx context.Context, method, url string, body []byte, headers map[string]string) (httpResult, error) {
+	request, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return httpResult{}, err
+	}
+
+	for key, value := range headers {
+		request.Header.Add(key, value)
+	}
+	injectTraceContext(ctx, request.Header)
+
+	resp, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return httpResult{}, err
+	}
+	defer resp.Body.Close()
+
+	respByte, err := io.ReadAll(resp.Body)
+
+	return httpResult{status: resp.StatusCode, body: respByte}, err
+}
+
+// doRequest works like DoRequestWithContext for requests made by SDK builders
+func doRequest(ctx context.Context, config *Config, info requestInfo, url string, method string, body any, headers map[string]string) ([]byte, error) {
+	data, err := json.Marshal(&body)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := send(ctx, config, info, method, url, data, headers)
+	return result.body, err
+}
+
+// doCheckedRequest works like doRequest, non-2xx responses fail with a *StatusError
+func doCheckedRequest(ctx context.Context, config *Config, info requestInfo, url string, method string, body any, headers map[string]string) ([]byte, error) {
+	data, err := json.Marshal(&body)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := send(ctx, config, info, method, url, data, headers)
+	if err != nil {
+		return result.body, err
+	}
+
+	return result.body, checkStatus(result)
+}
+
+// requestFunc is the signature of doRequest and doCheckedRequest
+type requestFunc func(ctx context.Context, config *Config, info requestInfo, url string, method string, body any, headers map[string]string) ([]byte, error)
+
+// requestChecking returns doCheckedRequest for builders with CheckStatus set, doRequest otherwise
+func requestChecking(check bool) requestFunc {
+	if check {
+		return doCheckedRequest
+	}
+	return doRequest
+}
+
+// sendFile sends a multipart body of a file upload. fileSize, the size of the
+// file without the multipart encoding, is reported to Config.Metrics when the
+// server accepted the upload.
+func sendFile(ctx context.Context, config *Config, info requestInfo, url, method string, headers map[string]string, body *bytes.Buffer, contentType string, fileSize int64) (httpResult, error) {
+	header := make(map[string]string, len(headers)+1)
+	for key, value := range headers {
+		header[key] = value
+	}
+	header["Content-Type"] = contentType
+
+	result, err := send(ctx, config, info, method, url, body.Bytes(), header)
+	if err == nil && checkStatus(result) == nil && config.Metrics != nil {
+		config.Metrics.BytesUploaded(info.operation, fileSize)
+	}
+
+	return result, err
+}
+
+// doFileRequest works like sendFile, non-2xx responses fail with a *StatusError
+func doFileRequest(ctx context.Context, config *Config, info requestInfo, url, method string, headers map[string]string, body *bytes.Buffer, contentType string, fileSize int64) ([]byte, error) {
+	result, err := sendFile(ctx, config, info, url, method, headers, body, contentType, fileSize)
+	if err != nil {
+		return result.body, err
+	}
+
+	return result.body, checkStatus(result)
+}
+
+// isRetryable reports whether a failed request with method may be sent again.
+// Only reads are, a write whose first attempt reached the server may apply twice.
+func isRetryable(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead
+}
+
+func shouldRetry(result httpResult, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	return result.status == http.StatusTooManyRequests || result.status >= 500
+}
+
+func logRequest(ctx context.Context, config *Config, info requestInfo, method, url string, body []byte, headers map[string]string, result httpResult, err error, attempt int, latency time.Duration) {
+	logger := config.Logger
+	if logger == nil {
+		return
+	}
+
+	failed := err != nil || result.status >= 400
+
+	level := slog.LevelDebug
+	if failed {
+		level = slog.LevelError
+	}
+	if !logger.Enabled(ctx, level) {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("operation", info.operation),
+		slog.String("method", method),
+		slog.String("path", urlPath(url)),
+		slog.Int("status", result.status),
+		slog.Duration("latency", latency),
+		slog.Int("attempt", attempt),
+	}
+	if info.collection != "" {
+		attrs = append(attrs, slog.String("collection", info.collection))
+	}
+
+	if level == slog.LevelDebug {
+		attrs = append(attrs, slog.Any("headers", redactHeaders(headers)))
+		if !strings.HasPrefix(headers["Content-Type"], "multipart/") {
+			attrs = append(attrs, slog.Any("body", redactBody(body)))
+		}
+	}
+
+	message := "ucode request"
+	if failed {
+		message = "ucode request failed"
+		if err != nil {
+			attrs = append(attrs, slog.String("error", err.Error()))
+		} else {
+			attrs = append(attrs, slog.String("response", truncate(string(result.body), 512)))
+		}
+	}
+
+	logger.LogAttrs(ctx, level, message, attrs...)
+}
+
+func urlPath(url string) string {
+	u, err := nurl.Parse(url)
+	if err != nil {
+		return ""
+	}
+	return u.Path
+}
+
+const redacted = "[REDACTED]"
+
+// isSensitive reports whether a header or field name holds a credential
+func isSensitive(name string) bool {
+	name = strings.ToLower(name)
+
+	for _, word := range []string{"api-key", "api_key", "apikey", "authorization", "cookie", "password", "token", "secret"} {
+		if strings.Contains(name, word) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func redactHeaders(headers map[string]string) map[string]string {
+	redactedHeaders := make(map[string]string, len(headers))
+	for key, value := range headers {
+		if isSensitive(key) {
+			value = redacted
+		}
+		redactedHeaders[key] = value
+	}
+	return redactedHeaders
+}
+
+// redactBody decodes a JSON body and replaces values of sensitive fields at any depth
+func redactBody(body []byte) any {
+	if len(body) == 0 {
+		return nil
+	}
+
+	var value any
+	if err := json.Unmarshal(body, &value); err != nil {
+		return truncate(string(body), 512)
+	}
+
+	redactValue(value)
+	return value
+}
+
+// RedactJSON replaces the values of credential fields like password or
+// access_token in a JSON body at any depth, the way request logs do.
+// Bodies that aren't JSON or hold no credentials are returned as is.
+func RedactJSON(body []byte) []byte {
+	var value any
+	if err := json.Unmarshal(body, &value); err != nil || !redactValue(value) {
+		return body
+	}
+
+	redactedBody, err := json.Marshal(value)
+	if err != nil {
+		return body
+	}
+	return redactedBody
+}
+
+// redactValue replaces sensitive fields of maps in value and reports whether it found any
+func redactValue(value any) bool {
+	found := false
+
+	switch v := value.(type) {
+	case map[string]any:
+		for key, field := range v {
+			if isSensitive(key) {
+				v[key] = redacted
+				found = true
+				continue
+			}
+			found = redactValue(field) || found
+		}
+	case []any:
+		for _, item := range v {
+			found = redactValue(item) || found
+		}
+	}
+
+	return found
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
+
+func orBackground(ctx context.Context) context.Context {
+	if ctx == nil {
+		return context.Background()
+	}
+	return ctx
+}
@@ -0,0 +1,112 @@
+package ucodesdk
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decodeLogs(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var record map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &record))
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestRequestLoggingAndRetries(t *testing.T) {
+	var calls atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"status":"OK","data":{"data":{"response":{"guid":"1"}}}}`))
+	}))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	api := New(&Config{
+		BaseURL:    server.URL,
+		AppId:      "secret-app-id",
+		MaxRetries: 2,
+		Logger:     slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})),
+	})
+
+	_, _, err := api.Items("order").GetSingle("1").Exec()
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), calls.Load())
+
+	records := decodeLogs(t, &buf)
+	require.Len(t, records, 2)
+
+	assert.Equal(t, "ERROR", records[0]["level"])
+	assert.Equal(t, "items.get_single", records[0]["operation"])
+	assert.Equal(t, "order", records[0]["collection"])
+	assert.Equal(t, float64(503), records[0]["status"])
+	assert.Equal(t, float64(1), records[0]["attempt"])
+
+	assert.Equal(t, "DEBUG", records[1]["level"])
+	assert.Equal(t, float64(2), records[1]["attempt"])
+	assert.Equal(t, "/v2/items/order/1", records[1]["path"])
+	assert.NotContains(t, buf.String(), "secret-app-id")
+}
+
+func TestRequestWritesNotRetried(t *testing.T) {
+	var calls atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	items := New(&Config{BaseURL: server.URL, MaxRetries: 2}).Items("order")
+
+	_, _, err := items.Update(map[string]any{"guid": "1"}).ExecSingle()
+	assert.Error(t, err)
+	_, _, err = items.Update(map[string]any{"objects": []any{}}).CheckStatus(true).ExecMultiple()
+	assert.Error(t, err)
+	_, err = items.Delete().Single("1").CheckStatus(true).Exec()
+	assert.Error(t, err)
+
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestRequestLoggingRedactsCredentials(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status":"OK"}`))
+	}))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	api := New(&Config{
+		BaseURL:     server.URL,
+		BaseAuthUrl: server.URL,
+		Logger:      slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})),
+	})
+
+	api.Auth().Login(map[string]any{
+		"username": "john",
+		"password": "hunter2",
+		"tokens":   map[string]any{"refresh_token": "abc"},
+	}).Headers(map[string]string{"X-API-KEY": "key"}).Exec()
+
+	records := decodeLogs(t, &buf)
+	require.Len(t, records, 1)
+	assert.Equal(t, "auth.login", records[0]["operation"])
+	assert.Equal(t, map[string]any{"username": "john", "password": redacted, "tokens": redacted, "project_id": ""}, records[0]["body"])
+	assert.Equal(t, redacted, records[0]["headers"].(map[string]any)["X-API-KEY"])
+	assert.NotContains(t, buf.String(), "hunter2")
+}
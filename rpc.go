@@ -0,0 +1,404 @@
+package ucodesdk
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+var ErrRPCBusy = errors.New("rpc server is busy")
+
+// rpcReplyTimeout limits how long Serve waits for the broker to accept a reply
+const rpcReplyTimeout = 10 * time.Second
+
+// RPCError is returned by Call when the handler replied with an error
+type RPCError struct {
+	Topic   string
+	Message string
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("rpc %s: %s", e.Topic, e.Message)
+}
+
+/*
+rpcEnvelope is the JSON payload of requests and replies. MQTT 3.1.1 has no
+correlation data or response topic properties, so they travel in the payload:
+
+	request: {"id": "...", "reply_to": "ucode/rpc/replies/...", "data": {...}}
+	reply:   {"id": "...", "data": {...}} or {"id": "...", "error": "..."}
+*/
+type rpcEnvelope struct {
+	ID      string          `json:"id"`
+	ReplyTo string          `json:"reply_to,omitempty"`
+	Data    json.RawMessage `json:"data,omitempty"`
+	Error   string          `json:"error,omitempty"`
+}
+
+type RPCRequest struct {
+	ID      string
+	Topic   string
+	ReplyTo string
+	Data    json.RawMessage
+}
+
+// Decode decodes the request data into v
+func (r RPCRequest) Decode(v any) error {
+	return json.Unmarshal(r.Data, v)
+}
+
+type RPCReply struct {
+	ID   string
+	Data json.RawMessage
+}
+
+// Decode decodes the reply data into v
+func (r RPCReply) Decode(v any) error {
+	return json.Unmarshal(r.Data, v)
+}
+
+// RPCHandler handles a request, the returned value is sent back as reply data
+type RPCHandler func(ctx context.Context, req RPCRequest) (any, error)
+
+func (u *object) MQTTRPC() *MQTTRPC {
+	timeout := u.config.RequestTimeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+
+	return &MQTTRPC{
+		mqtt:           u.mqtt,
+		inbox:          u.rpcInbox,
+		timeout:        timeout,
+		concurrency:    16,
+		handlerTimeout: 30 * time.Second,
+		handlers:       map[string]RPCHandler{},
+		logger:         u.config.Logger,
+	}
+}
+
+// Timeout limits how long Call waits for the reply, default Config.RequestTimeout or 10s
+func (r *MQTTRPC) Timeout(timeout time.Duration) *MQTTRPC {
+	r.timeout = timeout
+	return r
+}
+
+// Concurrency limits requests Serve handles at once, requests over the limit get ErrRPCBusy. Default 16.
+func (r *MQTTRPC) Concurrency(n int) *MQTTRPC {
+	if n > 0 {
+		r.concurrency = n
+	}
+	return r
+}
+
+// HandlerTimeout limits the duration of a handler call, default 30s
+func (r *MQTTRPC) HandlerTimeout(timeout time.Duration) *MQTTRPC {
+	r.handlerTimeout = timeout
+	return r
+}
+
+// Handle registers handler for requests on topic, topic may contain wildcards
+func (r *MQTTRPC) Handle(topic string, handler RPCHandler) *MQTTRPC {
+	r.handlers[topic] = handler
+	return r
+}
+
+/*
+Call publishes req to topic and waits for the reply.
+
+	reply, err := sdk.MQTTRPC().
+		Timeout(5 * time.Second).
+		Call(ctx, "devices/42/reboot", map[string]any{"delay": 10})
+
+	var status DeviceStatus
+	err = reply.Decode(&status)
+
+If the handler failed the error is a *RPCError.
+*/
+func (r *MQTTRPC) Call(ctx context.Context, topic string, req any) (RPCReply, error) {
+	if r.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.timeout)
+		defer cancel()
+	}
+
+	replyTo, err := r.inbox.ensure(ctx)
+	if err != nil {
+		return RPCReply{}, err
+	}
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		return RPCReply{}, fmt.Errorf("encoding rpc request: %w", err)
+	}
+
+	id := randomID()
+	replies := r.inbox.wait(id)
+	defer r.inbox.forget(id)
+
+	payload, err := json.Marshal(rpcEnvelope{ID: id, ReplyTo: replyTo, Data: data})
+	if err != nil {
+		return RPCReply{}, err
+	}
+
+	client, err := r.mqtt.connect(ctx)
+	if err != nil {
+		return RPCReply{}, err
+	}
+
+	if err := waitToken(ctx, client.Publish(topic, 1, false, payload)); err != nil {
+		return RPCReply{}, fmt.Errorf("publishing rpc request to %s: %w", topic, err)
+	}
+
+	select {
+	case reply := <-replies:
+		if reply.Error != "" {
+			return RPCReply{}, &RPCError{Topic: topic, Message: reply.Error}
+		}
+		return RPCReply{ID: reply.ID, Data: reply.Data}, nil
+	case <-ctx.Done():
+		return RPCReply{}, fmt.Errorf("waiting for rpc reply from %s: %w", topic, ctx.Err())
+	}
+}
+
+/*
+Serve subscribes to the topics of the registered handlers and replies to requests until ctx is done.
+
+	err := sdk.MQTTRPC().
+		Concurrency(8).
+		HandlerTimeout(5 * time.Second).
+		Handle("devices/42/reboot", reboot).
+		Serve(ctx)
+*/
+func (r *MQTTRPC) Serve(ctx context.Context) error {
+	if len(r.handlers) == 0 {
+		return errors.New("no rpc handlers registered")
+	}
+
+	var (
+		slots        = make(chan struct{}, r.concurrency)
+		wg           sync.WaitGroup
+		mu           sync.Mutex
+		closed       bool
+		unsubscribes []func() error
+	)
+
+	defer func() {
+		for _, unsubscribe := range unsubscribes {
+			unsubscribe()
+		}
+
+		// paho may still run the callback, it must not call wg.Add once Wait started
+		mu.Lock()
+		closed = true
+		mu.Unlock()
+		wg.Wait()
+	}()
+
+	for topic, handler := range r.handlers {
+		handler := handler
+
+		unsubscribe, err := r.mqtt.subscribe(ctx, topic, 1, func(client mqtt.Client, msg mqtt.Message) {
+			var req rpcEnvelope
+			if err := json.Unmarshal(msg.Payload(), &req); err != nil || req.ID == "" || req.ReplyTo == "" {
+				return
+			}
+
+			mu.Lock()
+			if closed {
+				mu.Unlock()
+				return
+			}
+			wg.Add(1)
+			mu.Unlock()
+
+			// replies wait for the broker, which must not block paho's callback
+			go func() {
+				defer wg.Done()
+
+				select {
+				case slots <- struct{}{}:
+					defer func() { <-slots }()
+				default:
+					r.reply(client, req, nil, ErrRPCBusy)
+					return
+				}
+
+				data, err := r.call(ctx, handler, RPCRequest{ID: req.ID, Topic: msg.Topic(), ReplyTo: req.ReplyTo, Data: req.Data})
+				r.reply(client, req, data, err)
+			}()
+		})
+		if err != nil {
+			return err
+		}
+		unsubscribes = append(unsubscribes, unsubscribe)
+	}
+
+	<-ctx.Done()
+	return nil
+}
+
+// call runs handler with HandlerTimeout, a handler ignoring ctx doesn't delay the reply
+func (r *MQTTRPC) call(ctx context.Context, handler RPCHandler, req RPCRequest) (any, error) {
+	if r.handlerTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.handlerTimeout)
+		defer cancel()
+	}
+
+	type result struct {
+		data any
+		err  error
+	}
+
+	done := make(chan result, 1)
+	go func() {
+		defer func() {
+			if rec := recover(); rec != nil {
+				done <- result{err: fmt.Errorf("panic: %v", rec)}
+			}
+		}()
+
+		data, err := handler(ctx, req)
+		done <- result{data, err}
+	}()
+
+	select {
+	case res := <-done:
+		return res.data, res.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// reply publishes the result of req, failures are logged to Config.Logger
+func (r *MQTTRPC) reply(client mqtt.Client, req rpcEnvelope, data any, err error) {
+	reply := rpcEnvelope{ID: req.ID}
+
+	if err == nil {
+		reply.Data, err = json.Marshal(data)
+	}
+	if err != nil {
+		reply.Data = nil
+		reply.Error = err.Error()
+	}
+
+	payload, err := json.Marshal(reply)
+	if err != nil {
+		r.logReplyError(req, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), rpcReplyTimeout)
+	defer cancel()
+
+	if err := waitToken(ctx, client.Publish(req.ReplyTo, 1, false, payload)); err != nil {
+		r.logReplyError(req, err)
+	}
+}
+
+func (r *MQTTRPC) logReplyError(req rpcEnvelope, err error) {
+	if r.logger == nil {
+		return
+	}
+
+	r.logger.Error("ucode rpc reply failed",
+		slog.String("id", req.ID),
+		slog.String("reply_to", req.ReplyTo),
+		slog.String("error", err.Error()),
+	)
+}
+
+// rpcInbox is the reply topic of the sdk, it is subscribed on the first Call
+type rpcInbox struct {
+	mqtt  *mqttManager
+	topic string
+
+	mu         sync.Mutex
+	subscribed bool
+	pending    map[string]chan rpcEnvelope
+}
+
+func newRPCInbox(manager *mqttManager) *rpcInbox {
+	return &rpcInbox{
+		mqtt:    manager,
+		topic:   "ucode/rpc/replies/" + randomID(),
+		pending: map[string]chan rpcEnvelope{},
+	}
+}
+
+// ensure subscribes to the inbox topic once. The subscribe runs without i.mu held,
+// so a slow broker doesn't block deliveries, Calls racing on the first subscribe
+// drop their extra handler.
+func (i *rpcInbox) ensure(ctx context.Context) (string, error) {
+	i.mu.Lock()
+	subscribed := i.subscribed
+	i.mu.Unlock()
+
+	if subscribed {
+		return i.topic, nil
+	}
+
+	unsubscribe, err := i.mqtt.subscribe(ctx, i.topic, 1, func(_ mqtt.Client, msg mqtt.Message) {
+		var reply rpcEnvelope
+		if err := json.Unmarshal(msg.Payload(), &reply); err != nil {
+			return
+		}
+		i.deliver(reply)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	i.mu.Lock()
+	subscribed = i.subscribed
+	i.subscribed = true
+	i.mu.Unlock()
+
+	if subscribed {
+		unsubscribe()
+	}
+
+	return i.topic, nil
+}
+
+func (i *rpcInbox) wait(id string) <-chan rpcEnvelope {
+	ch := make(chan rpcEnvelope, 1)
+
+	i.mu.Lock()
+	i.pending[id] = ch
+	i.mu.Unlock()
+
+	return ch
+}
+
+func (i *rpcInbox) forget(id string) {
+	i.mu.Lock()
+	delete(i.pending, id)
+	i.mu.Unlock()
+}
+
+func (i *rpcInbox) deliver(reply rpcEnvelope) {
+	i.mu.Lock()
+	ch, ok := i.pending[reply.ID]
+	delete(i.pending, reply.ID)
+	i.mu.Unlock()
+
+	if ok {
+		ch <- reply
+	}
+}
+
+func randomID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,104 @@
+package ucodesdk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRPCHandlerCall(t *testing.T) {
+	rpc := New(&Config{}).MQTTRPC().HandlerTimeout(20 * time.Millisecond)
+
+	data, err := rpc.call(context.Background(), func(ctx context.Context, req RPCRequest) (any, error) {
+		var body map[string]int
+		if err := req.Decode(&body); err != nil {
+			return nil, err
+		}
+		return body["n"] * 2, nil
+	}, RPCRequest{Data: json.RawMessage(`{"n":21}`)})
+	assert.NoError(t, err)
+	assert.Equal(t, 42, data)
+
+	_, err = rpc.call(context.Background(), func(ctx context.Context, req RPCRequest) (any, error) {
+		time.Sleep(time.Second)
+		return nil, nil
+	}, RPCRequest{})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	_, err = rpc.call(context.Background(), func(ctx context.Context, req RPCRequest) (any, error) {
+		panic("boom")
+	}, RPCRequest{})
+	assert.EqualError(t, err, "panic: boom")
+}
+
+func TestRPCInboxDeliver(t *testing.T) {
+	inbox := newRPCInbox(newMQTTManager(&Config{}))
+
+	replies := inbox.wait("1")
+	inbox.deliver(rpcEnvelope{ID: "2"})
+	inbox.deliver(rpcEnvelope{ID: "1", Data: json.RawMessage(`"ok"`)})
+
+	reply := <-replies
+	assert.Equal(t, json.RawMessage(`"ok"`), reply.Data)
+	assert.Empty(t, inbox.pending)
+
+	_, err := New(&Config{}).MQTTRPC().Call(context.Background(), "devices/1", nil)
+	assert.ErrorIs(t, err, ErrMQTTNotConfigured)
+}
+
+func TestRPCInboxEnsure(t *testing.T) {
+	var (
+		client  = &subscribeClient{tokens: make(chan *pendingToken, 1)}
+		manager = newMQTTManager(&Config{})
+		inbox   = newRPCInbox(manager)
+		errs    = make(chan error, 2)
+	)
+	manager.client = client
+
+	ensure := func() {
+		_, err := inbox.ensure(context.Background())
+		errs <- err
+	}
+
+	go ensure()
+	token := <-client.tokens
+	go ensure()
+
+	// replies are delivered while the subscribe is pending
+	delivered := make(chan struct{})
+	go func() {
+		inbox.deliver(rpcEnvelope{ID: "1"})
+		close(delivered)
+	}()
+	select {
+	case <-delivered:
+	case <-time.After(time.Second):
+		t.Fatal("deliver blocked by the pending subscribe")
+	}
+
+	close(token.done)
+	assert.NoError(t, <-errs)
+	assert.NoError(t, <-errs)
+
+	// racing Calls leave a single handler
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+	assert.Len(t, manager.subs[inbox.topic].handlers, 1)
+}
+
+func TestRPCReplyPublishFailure(t *testing.T) {
+	var buf bytes.Buffer
+	rpc := New(&Config{Logger: slog.New(slog.NewJSONHandler(&buf, nil))}).MQTTRPC()
+
+	rpc.reply(&publishClient{err: errors.New("not connected")}, rpcEnvelope{ID: "1", ReplyTo: "ucode/rpc/replies/x"}, "ok", nil)
+
+	assert.Contains(t, buf.String(), "ucode rpc reply failed")
+	assert.Contains(t, buf.String(), "not connected")
+	assert.Contains(t, buf.String(), "ucode/rpc/replies/x")
+}
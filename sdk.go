@@ -2,9 +2,11 @@ package ucodesdk
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"io"
+	"mime/multipart"
 	"net/http"
 
 	mqtt "github.com/eclipse/paho.mqtt.golang"
@@ -67,21 +69,81 @@ type UcodeApis interface {
 		Supported across MongoDB and PostgreSQL, providing flexibility for backend processing.
 	*/
 	Function(path string) FunctionI
+	/*
+		FanOut returns a builder invoking several functions concurrently.
+
+		Results are returned in the order of calls. Use FunctionCalls to invoke
+		one function with many payloads.
+
+		Usage:
+		sdk.FanOut(
+			ucodesdk.FunctionCall{Path: "calculate-price", Data: order},
+			ucodesdk.FunctionCall{Path: "reserve-stock", Data: order},
+		).
+			Concurrency(4). //default 4
+			FailFast(true). //default false, collect all results
+			Exec()
+	*/
+	FanOut(calls ...FunctionCall) *FanOut
+	/*
+		PubSub returns typed publish and subscribe helpers on the shared MQTT connection, see ConnectToMQTT.
+
+		Usage:
+		err := sdk.PubSub().
+			Codec(ucodesdk.MsgpackCodec). //default JSONCodec
+			Publish(ctx, "orders/1/paid", order, 1)
+
+		sub, err := ucodesdk.Subscribe(ctx, sdk.PubSub(), "orders/+/paid", func(order Order) {
+			...
+		})
+	*/
+	PubSub() *PubSub
+	/*
+		MQTTRPC returns a request/reply client and server on the shared MQTT connection.
+
+		Usage:
+		reply, err := sdk.MQTTRPC().
+			Timeout(5 * time.Second). //default Config.RequestTimeout or 10s
+			Call(ctx, "devices/42/reboot", request)
+
+		err := sdk.MQTTRPC().
+			Concurrency(8). //default 16
+			HandlerTimeout(5 * time.Second). //default 30s
+			Handle("devices/+/reboot", handler).
+			Serve(ctx)
+	*/
+	MQTTRPC() *MQTTRPC
 
 	Config() *Config
 	DoRequest(url string, method string, body any, headers map[string]string) ([]byte, error)
+	// DoFileRequest sends a multipart body and returns the response body whatever its status.
+	// The whole body of an accepted upload counts as uploaded bytes in Config.Metrics.
+	DoFileRequest(url, method string, headers map[string]string, body bytes.Buffer, writer *multipart.Writer) ([]byte, error)
+	/*
+		ConnectToMQTT returns the MQTT client shared by the sdk, connecting it on the first call.
+
+		Configure it with Config.MQTTBroker, MQTTClientID, MQTTCAFile/MQTTCertFile/MQTTKeyFile,
+		MQTTPersistentSession, MQTTKeepAlive, MQTTMaxReconnectInterval and MQTTOnStateChange.
+		The connection reconnects with backoff.
+	*/
 	ConnectToMQTT() (mqtt.Client, error)
 }
 
 func New(cfg *Config) UcodeApis {
+	manager := newMQTTManager(cfg)
+
 	return &object{
-		config: cfg,
+		config:   cfg,
+		mqtt:     manager,
+		rpcInbox: newRPCInbox(manager),
 	}
 }
 
 // UcodeAPI struct implements UcodeAPIInterface
 type object struct {
-	config *Config
+	config   *Config
+	mqtt     *mqttManager
+	rpcInbox *rpcInbox
 }
 
 func (u *object) Config() *Config {
@@ -117,38 +179,47 @@ func DoRequest(url string, method string, body any, headers map[string]string) (
 	return respByte, err
 }
 
-func (a *object) DoRequest(url string, method string, body any, headers map[string]string) ([]byte, error) {
+// DoRequestWithContext works like DoRequest, the request is canceled when ctx is done
+func DoRequestWithContext(ctx context.Context, url string, method string, body any, headers map[string]string) ([]byte, error) {
 	data, err := json.Marshal(&body)
 	if err != nil {
 		return nil, err
 	}
 
-	client := &http.Client{}
-
-	request, err := http.NewRequest(method, url, bytes.NewBuffer(data))
+	request, err := http.NewRequestWithContext(ctx, method, url, bytes.NewBuffer(data))
 	if err != nil {
 		return nil, err
 	}
 
-	// Add headers from the map
 	for key, value := range headers {
 		request.Header.Add(key, value)
 	}
 
-	resp, err := client.Do(request)
+	resp, err := http.DefaultClient.Do(request)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	respByte, err := io.ReadAll(resp.Body)
+
+	return respByte, err
+}
+
+func (a *object) DoRequest(url string, method string, body any, headers map[string]string) ([]byte, error) {
+	data, err := json.Marshal(&body)
 	if err != nil {
 		return nil, err
 	}
 
-	if resp.StatusCode >= 300 {
-		return respByte, errors.New(http.StatusText(resp.StatusCode))
+	result, err := send(context.Background(), a.config, requestInfo{operation: "request"}, method, url, data, headers)
+	if err != nil {
+		return nil, err
 	}
 
-	return respByte, err
+	if result.status >= 300 {
+		return result.body, errors.New(http.StatusText(result.status))
+	}
+
+	return result.body, nil
 }
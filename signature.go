@@ -0,0 +1,184 @@
+package ucodesdk
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	SignatureTimestampHeader = "X-Ucode-Timestamp"
+	SignatureHeader          = "X-Ucode-Signature"
+
+	signatureVersion = "v1="
+
+	// defaultSignedBodyBytes matches function.DefaultMaxBodyBytes
+	defaultSignedBodyBytes = 10 << 20
+)
+
+var (
+	ErrSignatureMissing = errors.New("request signature is missing")
+	ErrSignatureInvalid = errors.New("request signature is invalid")
+	ErrSignatureExpired = errors.New("request signature is outside the allowed window")
+	ErrSignatureReplay  = errors.New("request signature was already used")
+)
+
+// SignPayload returns the signature of body sent at timestamp:
+// "v1=" + hex(HMAC-SHA256(secret, "<unix timestamp>.<body>"))
+func SignPayload(secret string, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return signatureVersion + hex.EncodeToString(mac.Sum(nil))
+}
+
+// SignatureHeaders returns timestamp and signature headers for an outgoing request with body
+func SignatureHeaders(secret string, body []byte) map[string]string {
+	now := time.Now()
+
+	return map[string]string{
+		SignatureTimestampHeader: strconv.FormatInt(now.Unix(), 10),
+		SignatureHeader:          SignPayload(secret, now, body),
+	}
+}
+
+/*
+SignatureVerifier checks signatures created with SignPayload. Use it in functions
+exposed as HTTP endpoints to accept only requests signed with the shared secret:
+
+	verifier := &ucodesdk.SignatureVerifier{Secret: os.Getenv("UCODE_SIGNING_SECRET")}
+	http.Handle("/", verifier.Middleware(handler))
+
+or set it as function.Options.Verifier.
+*/
+type SignatureVerifier struct {
+	Secret string
+	// Window is the allowed difference between the request timestamp and now, default 5 minutes
+	Window time.Duration
+	// RejectReplays remembers signatures seen within Window and rejects them the second time
+	RejectReplays bool
+	// MaxBodyBytes limits the body Middleware reads, default 10 MB
+	MaxBodyBytes int64
+
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+func (v *SignatureVerifier) window() time.Duration {
+	if v.Window <= 0 {
+		return 5 * time.Minute
+	}
+	return v.Window
+}
+
+func (v *SignatureVerifier) maxBodyBytes() int64 {
+	if v.MaxBodyBytes <= 0 {
+		return defaultSignedBodyBytes
+	}
+	return v.MaxBodyBytes
+}
+
+// Verify checks the signature headers of a request with body. It fails with
+// ErrSigningSecretEmpty when Secret is empty, so a missing secret never lets
+// requests signed with an empty key through.
+func (v *SignatureVerifier) Verify(header http.Header, body []byte) error {
+	if v.Secret == "" {
+		return ErrSigningSecretEmpty
+	}
+
+	var (
+		signature = header.Get(SignatureHeader)
+		timestamp = header.Get(SignatureTimestampHeader)
+	)
+
+	if signature == "" || timestamp == "" {
+		return ErrSignatureMissing
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrSignatureInvalid
+	}
+
+	sentAt := time.Unix(unix, 0)
+	if d := time.Since(sentAt); d > v.window() || d < -v.window() {
+		return ErrSignatureExpired
+	}
+
+	expected := SignPayload(v.Secret, sentAt, body)
+	if !strings.HasPrefix(signature, signatureVersion) || !hmac.Equal([]byte(signature), []byte(expected)) {
+		return ErrSignatureInvalid
+	}
+
+	if v.RejectReplays {
+		return v.remember(signature, sentAt)
+	}
+
+	return nil
+}
+
+func (v *SignatureVerifier) remember(signature string, sentAt time.Time) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.seen == nil {
+		v.seen = map[string]time.Time{}
+	}
+
+	now := time.Now()
+	for sig, at := range v.seen {
+		if now.Sub(at) > v.window() {
+			delete(v.seen, sig)
+		}
+	}
+
+	if _, ok := v.seen[signature]; ok {
+		return ErrSignatureReplay
+	}
+	v.seen[signature] = sentAt
+
+	return nil
+}
+
+// Middleware rejects requests without a valid signature with 401 and an error
+// Response, bodies larger than MaxBodyBytes with 413
+func (v *SignatureVerifier) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var (
+			statusCode  = http.StatusUnauthorized
+			message     = "Unauthorized"
+			maxBytesErr *http.MaxBytesError
+			body, err   = io.ReadAll(http.MaxBytesReader(w, r.Body, v.maxBodyBytes()))
+		)
+
+		if errors.As(err, &maxBytesErr) {
+			statusCode, message = http.StatusRequestEntityTooLarge, "Request body too large"
+		} else if err == nil {
+			err = v.Verify(r.Header, body)
+		}
+
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(statusCode)
+			json.NewEncoder(w).Encode(Response{
+				Status: "error",
+				Data:   map[string]any{"message": message, "error": err.Error()},
+			})
+			return
+		}
+
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		next.ServeHTTP(w, r)
+	})
+}
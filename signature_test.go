@@ -0,0 +1,93 @@
+package ucodesdk
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignatureVerify(t *testing.T) {
+	var (
+		verifier = &SignatureVerifier{Secret: "secret", RejectReplays: true}
+		body     = []byte(`{"data":{"id":1}}`)
+		header   = http.Header{}
+	)
+
+	for key, value := range SignatureHeaders("secret", body) {
+		header.Set(key, value)
+	}
+
+	assert.NoError(t, verifier.Verify(header, body))
+	assert.ErrorIs(t, verifier.Verify(header, body), ErrSignatureReplay)
+	assert.ErrorIs(t, verifier.Verify(header, []byte(`{"data":{"id":2}}`)), ErrSignatureInvalid)
+	assert.ErrorIs(t, verifier.Verify(http.Header{}, body), ErrSignatureMissing)
+
+	old := time.Now().Add(-10 * time.Minute)
+	header.Set(SignatureTimestampHeader, strconv.FormatInt(old.Unix(), 10))
+	header.Set(SignatureHeader, SignPayload("secret", old, body))
+	assert.ErrorIs(t, verifier.Verify(header, body), ErrSignatureExpired)
+
+	// an unset secret rejects even requests signed with an empty key
+	for key, value := range SignatureHeaders("", body) {
+		header.Set(key, value)
+	}
+	assert.ErrorIs(t, (&SignatureVerifier{}).Verify(header, body), ErrSigningSecretEmpty)
+}
+
+func TestSignatureMiddlewareBodyLimit(t *testing.T) {
+	var (
+		verifier = &SignatureVerifier{Secret: "secret", MaxBodyBytes: 16}
+		handler  = verifier.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"status":"done"}`))
+		}))
+	)
+
+	send := func(body string) (int, Response) {
+		request := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		for key, value := range SignatureHeaders("secret", []byte(body)) {
+			request.Header.Set(key, value)
+		}
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, request)
+
+		var response Response
+		json.Unmarshal(rec.Body.Bytes(), &response)
+		return rec.Code, response
+	}
+
+	code, _ := send(`{"data":{}}`)
+	assert.Equal(t, http.StatusOK, code)
+
+	code, response := send(`{"data":{"id":"0123456789"}}`)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, code)
+	assert.Equal(t, "Request body too large", response.Data["message"])
+}
+
+func TestFunctionSignedInvoke(t *testing.T) {
+	verifier := &SignatureVerifier{Secret: "secret"}
+
+	server := httptest.NewServer(verifier.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.True(t, strings.Contains(string(body), `"id":1`))
+		w.Write([]byte(`{"status":"done","data":{"ok":true}}`))
+	})))
+	defer server.Close()
+
+	api := New(&Config{BaseURL: server.URL, FunctionSigningSecret: "secret"})
+
+	resp, _, err := api.Function("signed").Invoke(map[string]any{"id": 1}).Exec()
+	assert.NoError(t, err)
+	assert.Equal(t, "done", resp.Status)
+
+	resp, _, err = api.Function("signed").Invoke(map[string]any{"id": 1}).Sign("wrong").Exec()
+	assert.NoError(t, err)
+	assert.Equal(t, "error", resp.Status)
+}
@@ -0,0 +1,67 @@
+package ucodesdk
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignedURLLocal(t *testing.T) {
+	api := New(&Config{
+		BaseURL:            "https://api.test",
+		FileSigningSecret:  "secret",
+		FileSigningBaseURL: "https://files.example.com/private/",
+	})
+
+	signed, _, err := api.Files().SignedURL("file-1", time.Minute).Exec()
+	assert.NoError(t, err)
+
+	u, err := url.Parse(signed.Data.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, "/private/file-1", u.Path)
+
+	fileID, err := VerifySignedURL("secret", u, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, "file-1", fileID)
+
+	_, err = VerifySignedURL("secret", u, time.Now().Add(2*time.Minute))
+	assert.ErrorIs(t, err, ErrSignedURLExpired)
+
+	_, err = VerifySignedURL("other", u, time.Now())
+	assert.ErrorIs(t, err, ErrSignedURLInvalid)
+
+	u.Path = "/private/file-2"
+	_, err = VerifySignedURL("secret", u, time.Now())
+	assert.ErrorIs(t, err, ErrSignedURLInvalid)
+}
+
+func TestSignedURLEscapedID(t *testing.T) {
+	api := New(&Config{BaseURL: "https://api.test", FileSigningSecret: "secret"})
+
+	for _, id := range []string{"media/a b.png", "50%.pdf", "файл"} {
+		signed, _, err := api.Files().SignedURL(id, time.Minute).Exec()
+		assert.NoError(t, err)
+
+		u, err := url.Parse(signed.Data.URL)
+		assert.NoError(t, err)
+
+		fileID, err := VerifySignedURL("secret", u, time.Now())
+		assert.NoError(t, err, id)
+		assert.Equal(t, id, fileID)
+	}
+}
+
+func TestVerifySignedURLEmptySecret(t *testing.T) {
+	api := New(&Config{BaseURL: "https://api.test", FileSigningSecret: "secret"})
+
+	signed, _, err := api.Files().SignedURL("file-1", time.Minute).Exec()
+	assert.NoError(t, err)
+
+	u, err := url.Parse(signed.Data.URL)
+	assert.NoError(t, err)
+
+	_, err = VerifySignedURL("", u, time.Now())
+	assert.ErrorIs(t, err, ErrSigningSecretEmpty)
+}
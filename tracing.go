@@ -0,0 +1,66 @@
+package ucodesdk
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/ucode-io/ucode_sdk"
+
+// startSpan starts a client span named after the operation when Config.TracerProvider is set
+func startSpan(ctx context.Context, config *Config, info requestInfo, method, url string) (context.Context, trace.Span) {
+	if config.TracerProvider == nil {
+		return ctx, trace.SpanFromContext(context.Background())
+	}
+
+	attrs := []attribute.KeyValue{
+		attribute.String("ucode.operation", info.operation),
+		attribute.String("http.request.method", method),
+		attribute.String("url.path", urlPath(url)),
+	}
+	if info.collection != "" {
+		attrs = append(attrs, attribute.String("ucode.collection", info.collection))
+	}
+	if info.page > 0 {
+		attrs = append(attrs, attribute.Int("ucode.page", info.page))
+	}
+	if info.limit > 0 {
+		attrs = append(attrs, attribute.Int("ucode.limit", info.limit))
+	}
+
+	return config.TracerProvider.Tracer(tracerName).Start(ctx, info.operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+}
+
+func endSpan(span trace.Span, result httpResult, err error, attempts int) {
+	if !span.IsRecording() {
+		return
+	}
+
+	span.SetAttributes(attribute.Int("ucode.attempts", attempts))
+	if result.status > 0 {
+		span.SetAttributes(attribute.Int("http.response.status_code", result.status))
+	}
+
+	switch {
+	case err != nil:
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	case result.status >= 400:
+		span.SetStatus(codes.Error, http.StatusText(result.status))
+	}
+
+	span.End()
+}
+
+// injectTraceContext adds W3C traceparent and tracestate headers of the span in ctx
+func injectTraceContext(ctx context.Context, header http.Header) {
+	propagation.TraceContext{}.Inject(ctx, propagation.HeaderCarrier(header))
+}
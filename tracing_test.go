@@ -0,0 +1,120 @@
+package ucodesdk
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracing(t *testing.T) {
+	var traceparents []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparents = append(traceparents, r.Header.Get("traceparent"))
+		if r.URL.Path == "/v1/invoke_function/fail" {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+		w.Write([]byte(`{"status":"done"}`))
+	}))
+	defer server.Close()
+
+	var (
+		recorder = tracetest.NewSpanRecorder()
+		provider = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+		api      = New(&Config{BaseURL: server.URL, TracerProvider: provider})
+	)
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "handler")
+
+	_, _, err := api.Items("order").GetList().Page(2).Limit(5).Context(ctx).Exec()
+	require.NoError(t, err)
+
+	_, _, err = api.Function("fail").Invoke(nil).Context(ctx).Exec()
+	require.NoError(t, err)
+	parent.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+
+	list := spans[0]
+	assert.Equal(t, "items.get_list", list.Name())
+	assert.Equal(t, parent.SpanContext().TraceID(), list.SpanContext().TraceID())
+	assert.Equal(t, parent.SpanContext().SpanID(), list.Parent().SpanID())
+	assert.Contains(t, list.Attributes(), attribute.String("ucode.collection", "order"))
+	assert.Contains(t, list.Attributes(), attribute.Int("ucode.page", 2))
+	assert.Contains(t, list.Attributes(), attribute.Int("ucode.limit", 5))
+	assert.Contains(t, list.Attributes(), attribute.Int("http.response.status_code", 200))
+
+	invoke := spans[1]
+	assert.Equal(t, "function.invoke", invoke.Name())
+	assert.Equal(t, codes.Error, invoke.Status().Code)
+
+	require.Len(t, traceparents, 2)
+	assert.Equal(t, "00-"+list.SpanContext().TraceID().String()+"-"+list.SpanContext().SpanID().String()+"-01", traceparents[0])
+}
+
+func TestTracingFileBatches(t *testing.T) {
+	cleanup := newFileCleanupServer()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/files/folder_upload" {
+			var resp CreateFileResponse
+			resp.Data.ID = "f3"
+			json.NewEncoder(w).Encode(resp)
+			return
+		}
+		cleanup.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "logo.png"), []byte("logo"), 0o644))
+
+	var (
+		recorder = tracetest.NewSpanRecorder()
+		provider = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+		files    = New(&Config{BaseURL: server.URL, TracerProvider: provider}).Files()
+	)
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "cleanup")
+
+	_, _, err := files.UploadDir(dir, UploadDirOptions{}).Context(ctx).Exec()
+	require.NoError(t, err)
+
+	_, _, err = files.FindOrphans(map[string][]string{"order": {"invoice"}}).Delete(true).Context(ctx).Exec()
+	require.NoError(t, err)
+	parent.End()
+
+	// every request of the batches is a child of the caller's span, none is a new root
+	var names []string
+	for _, span := range recorder.Ended() {
+		if span.Name() == "cleanup" {
+			continue
+		}
+		names = append(names, span.Name())
+		assert.Equal(t, parent.SpanContext().SpanID(), span.Parent().SpanID(), span.Name())
+	}
+	assert.Equal(t, []string{"files.upload", "files.list", "items.get_list", "files.delete"}, names)
+}
+
+func TestTracingDisabled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get("traceparent"))
+		w.Write([]byte(`{"status":"done"}`))
+	}))
+	defer server.Close()
+
+	_, _, err := New(&Config{BaseURL: server.URL}).Items("order").GetList().Exec()
+	assert.NoError(t, err)
+}
@@ -0,0 +1,199 @@
+package ucodesdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cast"
+)
+
+// TriggerEventType is "<action>_<method>" of an item operation that fired a function
+type TriggerEventType string
+
+const (
+	BeforeCreate TriggerEventType = "before_create"
+	AfterCreate  TriggerEventType = "after_create"
+	BeforeUpdate TriggerEventType = "before_update"
+	AfterUpdate  TriggerEventType = "after_update"
+	BeforeDelete TriggerEventType = "before_delete"
+	AfterDelete  TriggerEventType = "after_delete"
+)
+
+var (
+	ErrInvalidTriggerEvent = errors.New("invalid trigger event")
+	ErrNoTriggerHandler    = errors.New("no handler for trigger event")
+)
+
+type TriggerUser struct {
+	ID           string         `json:"id"`
+	Login        string         `json:"login"`
+	Email        string         `json:"email"`
+	Phone        string         `json:"phone"`
+	RoleID       string         `json:"role_id"`
+	ClientTypeID string         `json:"client_type_id"`
+	Data         map[string]any `json:"-"`
+}
+
+/*
+TriggerEvent is the payload a function receives when an item operation made with
+DisableFaas(false) fires it. The platform sends it as sdk.Request.Data:
+
+	{
+		"method": "UPDATE",                  // CREATE, UPDATE, MULTIPLE_UPDATE, DELETE, DELETE_MANY
+		"action_type": "AFTER",              // BEFORE or AFTER
+		"table_slug": "order",
+		"object_ids": ["guid"],
+		"object_data": {...},                // created or updated values
+		"object_data_before_update": {...},  // UPDATE only
+		"user_info": {"id": "...", "role_id": "...", ...},
+		"app_id": "...", "project_id": "...", "environment_id": "..."
+	}
+*/
+type TriggerEvent struct {
+	Type          TriggerEventType
+	Action        string
+	Method        string
+	Collection    string
+	ObjectIDs     []string
+	ObjectData    map[string]any
+	PreviousData  map[string]any
+	User          TriggerUser
+	AppID         string
+	ProjectID     string
+	EnvironmentID string
+	Request       *Request
+}
+
+// Decode decodes ObjectData into v by JSON field names
+func (e TriggerEvent) Decode(v any) error {
+	body, err := json.Marshal(e.ObjectData)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, v)
+}
+
+// DecodePrevious decodes PreviousData into v by JSON field names
+func (e TriggerEvent) DecodePrevious(v any) error {
+	body, err := json.Marshal(e.PreviousData)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, v)
+}
+
+func ParseTriggerEvent(req *Request) (TriggerEvent, error) {
+	var (
+		data  = req.Data
+		event = TriggerEvent{
+			Action:        strings.ToUpper(cast.ToString(data["action_type"])),
+			Method:        strings.ToUpper(cast.ToString(data["method"])),
+			Collection:    cast.ToString(data["table_slug"]),
+			ObjectIDs:     cast.ToStringSlice(data["object_ids"]),
+			ObjectData:    cast.ToStringMap(data["object_data"]),
+			PreviousData:  cast.ToStringMap(data["object_data_before_update"]),
+			AppID:         cast.ToString(data["app_id"]),
+			ProjectID:     cast.ToString(data["project_id"]),
+			EnvironmentID: cast.ToString(data["environment_id"]),
+			Request:       req,
+		}
+	)
+
+	var kind string
+	switch event.Method {
+	case "CREATE":
+		kind = "create"
+	case "UPDATE", "MULTIPLE_UPDATE":
+		kind = "update"
+	case "DELETE", "DELETE_MANY":
+		kind = "delete"
+	default:
+		return event, fmt.Errorf("%w: unknown method %q", ErrInvalidTriggerEvent, event.Method)
+	}
+
+	switch event.Action {
+	case "BEFORE", "AFTER":
+		event.Type = TriggerEventType(strings.ToLower(event.Action) + "_" + kind)
+	default:
+		return event, fmt.Errorf("%w: unknown action type %q", ErrInvalidTriggerEvent, event.Action)
+	}
+
+	if guid := cast.ToString(event.ObjectData["guid"]); guid != "" && len(event.ObjectIDs) == 0 {
+		event.ObjectIDs = []string{guid}
+	}
+
+	user := cast.ToStringMap(data["user_info"])
+	event.User = TriggerUser{
+		ID:           cast.ToString(user["id"]),
+		Login:        cast.ToString(user["login"]),
+		Email:        cast.ToString(user["email"]),
+		Phone:        cast.ToString(user["phone"]),
+		RoleID:       cast.ToString(user["role_id"]),
+		ClientTypeID: cast.ToString(user["client_type_id"]),
+		Data:         user,
+	}
+	if event.User.ID == "" {
+		event.User.ID = cast.ToString(data["user_id"])
+	}
+
+	return event, nil
+}
+
+type TriggerHandler func(ctx context.Context, event TriggerEvent, ucode UcodeApis) (any, error)
+
+/*
+TriggerDispatcher routes trigger requests to handlers by event type and collection.
+Its Dispatch method has the signature of function.HandlerFunc:
+
+	dispatcher := ucodesdk.NewTriggerDispatcher().
+		On(ucodesdk.AfterCreate, "order", notifyWarehouse).
+		On(ucodesdk.BeforeDelete, "", forbidDelete) // any collection
+
+	return function.Handle(dispatcher.Dispatch)
+*/
+type TriggerDispatcher struct {
+	handlers map[TriggerEventType]map[string]TriggerHandler
+	fallback TriggerHandler
+}
+
+func NewTriggerDispatcher() *TriggerDispatcher {
+	return &TriggerDispatcher{handlers: map[TriggerEventType]map[string]TriggerHandler{}}
+}
+
+// On registers h for eventType in collection, an empty collection matches every collection
+func (d *TriggerDispatcher) On(eventType TriggerEventType, collection string, h TriggerHandler) *TriggerDispatcher {
+	if d.handlers[eventType] == nil {
+		d.handlers[eventType] = map[string]TriggerHandler{}
+	}
+	d.handlers[eventType][collection] = h
+	return d
+}
+
+// Default handles events without a registered handler, otherwise Dispatch returns ErrNoTriggerHandler
+func (d *TriggerDispatcher) Default(h TriggerHandler) *TriggerDispatcher {
+	d.fallback = h
+	return d
+}
+
+func (d *TriggerDispatcher) Dispatch(ctx context.Context, req *Request, ucode UcodeApis) (any, error) {
+	event, err := ParseTriggerEvent(req)
+	if err != nil {
+		return nil, err
+	}
+
+	handlers := d.handlers[event.Type]
+	if h, ok := handlers[event.Collection]; ok {
+		return h(ctx, event, ucode)
+	}
+	if h, ok := handlers[""]; ok {
+		return h(ctx, event, ucode)
+	}
+	if d.fallback != nil {
+		return d.fallback(ctx, event, ucode)
+	}
+
+	return nil, fmt.Errorf("%w: %s on %s", ErrNoTriggerHandler, event.Type, event.Collection)
+}
@@ -0,0 +1,59 @@
+package ucodesdk
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTriggerDispatcher(t *testing.T) {
+	type order struct {
+		Status string `json:"status"`
+	}
+
+	var got TriggerEvent
+
+	dispatcher := NewTriggerDispatcher().
+		On(AfterUpdate, "order", func(ctx context.Context, event TriggerEvent, ucode UcodeApis) (any, error) {
+			got = event
+			return "order", nil
+		}).
+		On(BeforeDelete, "", func(ctx context.Context, event TriggerEvent, ucode UcodeApis) (any, error) {
+			return nil, errors.New("deleting is forbidden")
+		})
+
+	result, err := dispatcher.Dispatch(context.Background(), &Request{Data: map[string]any{
+		"method":                    "UPDATE",
+		"action_type":               "AFTER",
+		"table_slug":                "order",
+		"object_data":               map[string]any{"guid": "o1", "status": "paid"},
+		"object_data_before_update": map[string]any{"guid": "o1", "status": "new"},
+		"user_info":                 map[string]any{"id": "u1", "role_id": "r1"},
+	}}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "order", result)
+	assert.Equal(t, AfterUpdate, got.Type)
+	assert.Equal(t, []string{"o1"}, got.ObjectIDs)
+	assert.Equal(t, "r1", got.User.RoleID)
+
+	var current, previous order
+	assert.NoError(t, got.Decode(&current))
+	assert.NoError(t, got.DecodePrevious(&previous))
+	assert.Equal(t, "paid", current.Status)
+	assert.Equal(t, "new", previous.Status)
+
+	_, err = dispatcher.Dispatch(context.Background(), &Request{Data: map[string]any{
+		"method": "DELETE", "action_type": "BEFORE", "table_slug": "house",
+	}}, nil)
+	assert.EqualError(t, err, "deleting is forbidden")
+
+	_, err = dispatcher.Dispatch(context.Background(), &Request{Data: map[string]any{
+		"method": "CREATE", "action_type": "AFTER", "table_slug": "house",
+	}}, nil)
+	assert.ErrorIs(t, err, ErrNoTriggerHandler)
+
+	_, err = dispatcher.Dispatch(context.Background(), &Request{Data: map[string]any{}}, nil)
+	assert.ErrorIs(t, err, ErrInvalidTriggerEvent)
+}
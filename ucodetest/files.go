@@ -0,0 +1,153 @@
+package ucodetest
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/spf13/cast"
+	sdk "github.com/ucode-io/ucode_sdk"
+)
+
+func (s *Server) handleFiles(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/v1/files"), "/"), "/")
+
+	switch {
+	case parts[0] == "folder_upload" && r.Method == http.MethodPost:
+		s.uploadFileHandler(w, r)
+	case parts[0] == "" && r.Method == http.MethodGet:
+		s.getFilesHandler(w, r)
+	case len(parts) == 1 && r.Method == http.MethodDelete:
+		s.deleteFileHandler(w, parts[0])
+	case len(parts) == 2 && parts[1] == "signed-url" && r.Method == http.MethodGet:
+		s.signedURLHandler(w, r, parts[0])
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+func (s *Server) uploadFileHandler(w http.ResponseWriter, r *http.Request) {
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	defer file.Close()
+
+	var (
+		shaHash = sha256.New()
+		md5Hash = md5.New()
+		folder  = r.URL.Query().Get("folder_name")
+		name    = path.Base(header.Filename)
+	)
+
+	size, err := io.Copy(io.MultiWriter(shaHash, md5Hash), file)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	id := s.newID()
+	object := sdk.FileObject{
+		ID:               id,
+		Title:            name,
+		Storage:          folder,
+		FileNameDisk:     path.Join(folder, id+"_"+name),
+		FileNameDownload: name,
+		Link:             s.URL + "/storage/" + path.Join(folder, id+"_"+name),
+		FileSize:         int(size),
+	}
+	s.files = append(s.files, object)
+	s.mu.Unlock()
+
+	var resp sdk.CreateFileResponse
+	resp.Status = "CREATED"
+	resp.Data.ID = object.ID
+	resp.Data.Title = object.Title
+	resp.Data.Storage = object.Storage
+	resp.Data.FileNameDisk = object.FileNameDisk
+	resp.Data.FileNameDownload = object.FileNameDownload
+	resp.Data.Link = object.Link
+	resp.Data.FileSize = object.FileSize
+	resp.Data.SHA256 = hex.EncodeToString(shaHash.Sum(nil))
+	resp.Data.MD5 = hex.EncodeToString(md5Hash.Sum(nil))
+
+	writeJSON(w, http.StatusCreated, resp)
+}
+
+func (s *Server) getFilesHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		query  = r.URL.Query()
+		folder = query.Get("folder_name")
+		offset = cast.ToInt(query.Get("offset"))
+		limit  = cast.ToInt(query.Get("limit"))
+		files  = []sdk.FileObject{}
+	)
+
+	s.mu.Lock()
+	for _, file := range s.files {
+		if folder == "" || file.Storage == folder {
+			files = append(files, file)
+		}
+	}
+	s.mu.Unlock()
+
+	var resp sdk.FileListResponse
+	resp.Status = "OK"
+	resp.Data.Count = len(files)
+
+	if offset > len(files) {
+		offset = len(files)
+	}
+	files = files[offset:]
+	if limit > 0 && limit < len(files) {
+		files = files[:limit]
+	}
+	resp.Data.Files = files
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) deleteFileHandler(w http.ResponseWriter, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, file := range s.files {
+		if file.ID == id {
+			s.files = append(s.files[:i], s.files[i+1:]...)
+			writeJSON(w, http.StatusOK, map[string]any{"status": "OK", "data": map[string]any{}})
+			return
+		}
+	}
+
+	writeError(w, http.StatusNotFound, "file not found")
+}
+
+func (s *Server) signedURLHandler(w http.ResponseWriter, r *http.Request, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, file := range s.files {
+		if file.ID == id {
+			var (
+				resp      sdk.SignedURLResponse
+				expiresIn = time.Duration(cast.ToInt64(r.URL.Query().Get("expires_in"))) * time.Second
+			)
+
+			resp.Status = "OK"
+			resp.Data.ExpiresAt = time.Now().Add(expiresIn).Truncate(time.Second)
+			resp.Data.URL = file.Link + "?expires=" + cast.ToString(resp.Data.ExpiresAt.Unix())
+
+			writeJSON(w, http.StatusOK, resp)
+			return
+		}
+	}
+
+	writeError(w, http.StatusNotFound, "file not found")
+}
@@ -0,0 +1,408 @@
+package ucodetest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cast"
+)
+
+var listReservedKeys = map[string]bool{
+	"offset":         true,
+	"limit":          true,
+	"search":         true,
+	"order":          true,
+	"view_fields":    true,
+	"with_relations": true,
+}
+
+func (s *Server) handleItems(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/v2/items/"), "/"), "/")
+	if parts[0] == "" {
+		writeError(w, http.StatusNotFound, "collection is empty")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if statusCode, ok := s.rejected[parts[0]]; ok && r.Method != http.MethodGet && !(len(parts) == 2 && parts[1] == "aggregation") {
+		writeError(w, statusCode, fmt.Sprintf("writes to %s are rejected", parts[0]))
+		return
+	}
+
+	switch {
+	case len(parts) == 1 && r.Method == http.MethodPost:
+		s.createItemHandler(w, r, parts[0])
+	case len(parts) == 1 && r.Method == http.MethodPut:
+		s.updateItemHandler(w, r, parts[0])
+	case len(parts) == 1 && r.Method == http.MethodPatch:
+		s.updateItemsHandler(w, r, parts[0])
+	case len(parts) == 1 && r.Method == http.MethodDelete:
+		s.deleteItemsHandler(w, r, parts[0])
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		s.getListHandler(w, r, parts[0])
+	case len(parts) == 2 && parts[1] == "aggregation" && r.Method == http.MethodPost:
+		s.aggregationHandler(w, parts[0])
+	case len(parts) == 2 && r.Method == http.MethodGet:
+		s.getSingleHandler(w, parts[0], parts[1])
+	case len(parts) == 2 && r.Method == http.MethodDelete:
+		s.deleteItemHandler(w, parts[0], parts[1])
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+func decodeActionBody(r *http.Request) (map[string]any, error) {
+	var body struct {
+		Data map[string]any `json:"data"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+
+	if body.Data == nil {
+		return nil, fmt.Errorf("data is empty")
+	}
+
+	return body.Data, nil
+}
+
+func (s *Server) createItemHandler(w http.ResponseWriter, r *http.Request, collection string) {
+	data, err := decodeActionBody(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	item := s.createItem(collection, data)
+
+	writeJSON(w, http.StatusCreated, map[string]any{
+		"status": "CREATED",
+		"data":   map[string]any{"data": item},
+	})
+}
+
+func (s *Server) updateItemHandler(w http.ResponseWriter, r *http.Request, collection string) {
+	data, err := decodeActionBody(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	item, ok := s.updateItem(collection, data)
+	if !ok {
+		writeError(w, http.StatusNotFound, "object not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"status": "OK",
+		"data":   map[string]any{"table_slug": collection, "data": item},
+	})
+}
+
+func (s *Server) updateItemsHandler(w http.ResponseWriter, r *http.Request, collection string) {
+	data, err := decodeActionBody(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var objects []map[string]any
+	for _, object := range cast.ToSlice(data["objects"]) {
+		fields := cast.ToStringMap(object)
+
+		if cast.ToBool(fields["is_new"]) {
+			delete(fields, "is_new")
+			objects = append(objects, s.createItem(collection, fields))
+			continue
+		}
+
+		if item, ok := s.updateItem(collection, fields); ok {
+			objects = append(objects, item)
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"status": "OK",
+		"data":   map[string]any{"data": map[string]any{"objects": objects}},
+	})
+}
+
+func (s *Server) deleteItemsHandler(w http.ResponseWriter, r *http.Request, collection string) {
+	var body struct {
+		IDs []string `json:"ids"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	for _, id := range body.IDs {
+		s.deleteItem(collection, id)
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"status": "OK", "data": map[string]any{}})
+}
+
+func (s *Server) deleteItemHandler(w http.ResponseWriter, collection, guid string) {
+	if !s.deleteItem(collection, guid) {
+		writeError(w, http.StatusNotFound, "object not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"status": "OK", "data": map[string]any{}})
+}
+
+func (s *Server) getSingleHandler(w http.ResponseWriter, collection, guid string) {
+	item, ok := s.collection(collection).items[guid]
+	if !ok {
+		writeError(w, http.StatusNotFound, "object not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"status": "OK",
+		"data":   map[string]any{"data": map[string]any{"response": copyItem(item)}},
+	})
+}
+
+func (s *Server) getListHandler(w http.ResponseWriter, r *http.Request, collection string) {
+	var (
+		query  = r.URL.Query()
+		filter = map[string]any{}
+		offset = cast.ToInt(query.Get("offset"))
+		limit  = cast.ToInt(query.Get("limit"))
+		c      = s.collection(collection)
+		items  []map[string]any
+	)
+
+	if data := query.Get("data"); data != "" {
+		if err := json.Unmarshal([]byte(data), &filter); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	for _, guid := range c.order {
+		if matchItem(c.items[guid], filter) {
+			items = append(items, copyItem(c.items[guid]))
+		}
+	}
+
+	sortItems(items, cast.ToStringMap(filter["order"]))
+
+	count := len(items)
+	if offset > len(items) {
+		offset = len(items)
+	}
+	items = items[offset:]
+	if limit > 0 && limit < len(items) {
+		items = items[:limit]
+	}
+
+	if items == nil {
+		items = []map[string]any{}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"status": "OK",
+		"data":   map[string]any{"data": map[string]any{"count": count, "response": items}},
+	})
+}
+
+func (s *Server) aggregationHandler(w http.ResponseWriter, collection string) {
+	var (
+		c     = s.collection(collection)
+		items = make([]map[string]any, 0, len(c.order))
+	)
+
+	for _, guid := range c.order {
+		items = append(items, copyItem(c.items[guid]))
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"status": "OK",
+		"data":   map[string]any{"data": map[string]any{"data": items}},
+	})
+}
+
+func (s *Server) collection(name string) *collection {
+	c, ok := s.collections[name]
+	if !ok {
+		c = &collection{items: map[string]map[string]any{}}
+		s.collections[name] = c
+	}
+	return c
+}
+
+func (s *Server) createItem(collection string, data map[string]any) map[string]any {
+	var (
+		c    = s.collection(collection)
+		item = copyItem(data)
+	)
+
+	guid := cast.ToString(item["guid"])
+	if guid == "" {
+		guid = s.newID()
+		item["guid"] = guid
+	}
+
+	if _, exists := c.items[guid]; !exists {
+		c.order = append(c.order, guid)
+	}
+	c.items[guid] = item
+
+	return copyItem(item)
+}
+
+func (s *Server) updateItem(collection string, data map[string]any) (map[string]any, bool) {
+	item, ok := s.collection(collection).items[cast.ToString(data["guid"])]
+	if !ok {
+		return nil, false
+	}
+
+	for key, value := range data {
+		item[key] = value
+	}
+
+	return copyItem(item), true
+}
+
+func (s *Server) deleteItem(collection, guid string) bool {
+	c := s.collection(collection)
+	if _, ok := c.items[guid]; !ok {
+		return false
+	}
+
+	delete(c.items, guid)
+	for i, id := range c.order {
+		if id == guid {
+			c.order = append(c.order[:i], c.order[i+1:]...)
+			break
+		}
+	}
+
+	return true
+}
+
+// matchItem supports equality, lists as "in" and $eq, $ne, $gt, $gte, $lt, $lte, $in, $nin operators
+func matchItem(item map[string]any, filter map[string]any) bool {
+	for key, condition := range filter {
+		if listReservedKeys[key] {
+			continue
+		}
+
+		value := item[key]
+
+		switch c := condition.(type) {
+		case []any:
+			if !containsValue(c, value) {
+				return false
+			}
+		case map[string]any:
+			for op, operand := range c {
+				if !matchOperator(op, value, operand) {
+					return false
+				}
+			}
+		default:
+			if !equalValues(value, condition) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func matchOperator(op string, value, operand any) bool {
+	switch op {
+	case "$eq":
+		return equalValues(value, operand)
+	case "$ne":
+		return !equalValues(value, operand)
+	case "$gt":
+		return compareValues(value, operand) > 0
+	case "$gte":
+		return compareValues(value, operand) >= 0
+	case "$lt":
+		return compareValues(value, operand) < 0
+	case "$lte":
+		return compareValues(value, operand) <= 0
+	case "$in":
+		return containsValue(cast.ToSlice(operand), value)
+	case "$nin":
+		return !containsValue(cast.ToSlice(operand), value)
+	}
+
+	return false
+}
+
+func containsValue(list []any, value any) bool {
+	for _, elem := range list {
+		if equalValues(elem, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func equalValues(a, b any) bool {
+	return compareValues(a, b) == 0
+}
+
+// compareValues compares numbers numerically and everything else as strings
+func compareValues(a, b any) int {
+	af, aErr := strconv.ParseFloat(cast.ToString(a), 64)
+	bf, bErr := strconv.ParseFloat(cast.ToString(b), 64)
+
+	if aErr == nil && bErr == nil {
+		switch {
+		case af < bf:
+			return -1
+		case af > bf:
+			return 1
+		}
+		return 0
+	}
+
+	return strings.Compare(cast.ToString(a), cast.ToString(b))
+}
+
+func sortItems(items []map[string]any, order map[string]any) {
+	fields := make([]string, 0, len(order))
+	for field := range order {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	sort.SliceStable(items, func(i, j int) bool {
+		for _, field := range fields {
+			cmp := compareValues(items[i][field], items[j][field])
+			if cmp == 0 {
+				continue
+			}
+			if cast.ToInt(order[field]) < 0 {
+				return cmp > 0
+			}
+			return cmp < 0
+		}
+		return false
+	})
+}
+
+func copyItem(item map[string]any) map[string]any {
+	copied := make(map[string]any, len(item))
+	for key, value := range item {
+		copied[key] = value
+	}
+	return copied
+}
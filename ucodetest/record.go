@@ -0,0 +1,171 @@
+package ucodetest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+
+	sdk "github.com/ucode-io/ucode_sdk"
+)
+
+// Interaction is one recorded request to the Ucode API and its response
+type Interaction struct {
+	Method       string `json:"method"`
+	URI          string `json:"uri"`
+	RequestBody  string `json:"request_body,omitempty"`
+	StatusCode   int    `json:"status_code"`
+	ContentType  string `json:"content_type,omitempty"`
+	ResponseBody string `json:"response_body"`
+}
+
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Recorder is a proxy to a real Ucode API that saves every interaction to a
+// cassette file, to be served later by a Replayer. Headers are not saved and
+// credentials in bodies, like passwords and tokens, are redacted.
+type Recorder struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	target   string
+	file     string
+	cassette Cassette
+}
+
+func NewRecorder(target, file string) *Recorder {
+	rec := &Recorder{target: strings.TrimSuffix(target, "/"), file: file}
+	rec.Server = httptest.NewServer(http.HandlerFunc(rec.proxy))
+	return rec
+}
+
+func (rec *Recorder) proxy(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	request, err := http.NewRequestWithContext(r.Context(), r.Method, rec.target+r.URL.RequestURI(), bytes.NewReader(body))
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	request.Header = r.Header.Clone()
+
+	resp, err := http.DefaultClient.Do(request)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+
+	interaction := Interaction{
+		Method:       r.Method,
+		URI:          r.URL.RequestURI(),
+		StatusCode:   resp.StatusCode,
+		ContentType:  resp.Header.Get("Content-Type"),
+		ResponseBody: string(sdk.RedactJSON(respBody)),
+	}
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") || json.Valid(body) {
+		interaction.RequestBody = string(sdk.RedactJSON(body))
+	}
+
+	rec.mu.Lock()
+	rec.cassette.Interactions = append(rec.cassette.Interactions, interaction)
+	err = saveCassette(rec.file, rec.cassette)
+	rec.mu.Unlock()
+
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if interaction.ContentType != "" {
+		w.Header().Set("Content-Type", interaction.ContentType)
+	}
+	w.WriteHeader(resp.StatusCode)
+	w.Write(respBody)
+}
+
+// Replayer serves responses from a cassette written by Recorder. Requests are
+// matched by method and URI, repeated requests get the recorded responses in
+// order and the last one once they run out.
+type Replayer struct {
+	*httptest.Server
+
+	mu    sync.Mutex
+	calls map[string]int
+	byKey map[string][]Interaction
+}
+
+func NewReplayer(file string) (*Replayer, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var cassette Cassette
+	if err := json.Unmarshal(data, &cassette); err != nil {
+		return nil, fmt.Errorf("reading cassette %s: %w", file, err)
+	}
+
+	rep := &Replayer{calls: map[string]int{}, byKey: map[string][]Interaction{}}
+	for _, interaction := range cassette.Interactions {
+		key := interaction.Method + " " + interaction.URI
+		rep.byKey[key] = append(rep.byKey[key], interaction)
+	}
+
+	rep.Server = httptest.NewServer(http.HandlerFunc(rep.replay))
+
+	return rep, nil
+}
+
+func (rep *Replayer) replay(w http.ResponseWriter, r *http.Request) {
+	key := r.Method + " " + r.URL.RequestURI()
+
+	rep.mu.Lock()
+	interactions := rep.byKey[key]
+	call := rep.calls[key]
+	rep.calls[key]++
+	rep.mu.Unlock()
+
+	if len(interactions) == 0 {
+		writeError(w, http.StatusNotFound, "no recorded interaction for "+key)
+		return
+	}
+
+	if call >= len(interactions) {
+		call = len(interactions) - 1
+	}
+	interaction := interactions[call]
+
+	if interaction.ContentType != "" {
+		w.Header().Set("Content-Type", interaction.ContentType)
+	}
+	w.WriteHeader(interaction.StatusCode)
+	io.WriteString(w, interaction.ResponseBody)
+}
+
+func saveCassette(file string, cassette Cassette) error {
+	data, err := json.MarshalIndent(cassette, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(file, data, 0o644)
+}
@@ -0,0 +1,97 @@
+package ucodetest
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	sdk "github.com/ucode-io/ucode_sdk"
+)
+
+func TestRecordReplay(t *testing.T) {
+	target := NewServer()
+	defer target.Close()
+	target.Seed("order", map[string]any{"guid": "1", "status": "new"})
+
+	cassette := filepath.Join(t.TempDir(), "api.json")
+
+	rec := NewRecorder(target.URL, cassette)
+	api := sdk.New(&sdk.Config{BaseURL: rec.URL})
+
+	first, _, err := api.Items("order").GetSingle("1").Exec()
+	assert.NoError(t, err)
+
+	_, _, err = api.Items("order").Update(map[string]any{"guid": "1", "status": "paid"}).ExecSingle()
+	assert.NoError(t, err)
+
+	second, _, err := api.Items("order").GetSingle("1").Exec()
+	assert.NoError(t, err)
+	rec.Close()
+
+	data, err := os.ReadFile(cassette)
+	assert.NoError(t, err)
+
+	var saved Cassette
+	assert.NoError(t, json.Unmarshal(data, &saved))
+	assert.Len(t, saved.Interactions, 3)
+	assert.Equal(t, http.MethodPut, saved.Interactions[1].Method)
+	assert.Contains(t, saved.Interactions[1].RequestBody, `"paid"`)
+
+	rep, err := NewReplayer(cassette)
+	assert.NoError(t, err)
+	defer rep.Close()
+
+	api = sdk.New(&sdk.Config{BaseURL: rep.URL})
+
+	// repeated requests get the recorded responses in order, then the last one
+	for _, want := range []string{"new", "paid", "paid"} {
+		got, _, err := api.Items("order").GetSingle("1").Exec()
+		assert.NoError(t, err)
+		assert.Equal(t, want, got.Data.Data.Response["status"])
+	}
+	assert.Equal(t, "new", first.Data.Data.Response["status"])
+	assert.Equal(t, "paid", second.Data.Data.Response["status"])
+
+	// requests that weren't recorded fail with 404
+	var statusErr *sdk.StatusError
+	_, _, err = api.Items("order").GetList().CheckStatus(true).Exec()
+	assert.True(t, errors.As(err, &statusErr))
+	assert.Equal(t, http.StatusNotFound, statusErr.StatusCode)
+}
+
+func TestRecorderRedactsCredentials(t *testing.T) {
+	target := NewServer()
+	defer target.Close()
+
+	cassette := filepath.Join(t.TempDir(), "api.json")
+
+	rec := NewRecorder(target.URL, cassette)
+	api := sdk.New(&sdk.Config{BaseURL: rec.URL, AppId: "secret-app-id"})
+
+	// the live response isn't redacted, only the cassette
+	created, _, err := api.Items("user").Create(map[string]any{"login": "john", "password": "hunter2"}).Exec()
+	assert.NoError(t, err)
+	assert.Equal(t, "hunter2", created.Data.Data["password"])
+	rec.Close()
+
+	data, err := os.ReadFile(cassette)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "hunter2")
+	assert.NotContains(t, string(data), "secret-app-id")
+	assert.Contains(t, string(data), "john")
+}
+
+func TestNewReplayerErrors(t *testing.T) {
+	_, err := NewReplayer(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+
+	invalid := filepath.Join(t.TempDir(), "invalid.json")
+	assert.NoError(t, os.WriteFile(invalid, []byte("{"), 0o644))
+
+	_, err = NewReplayer(invalid)
+	assert.ErrorContains(t, err, "reading cassette")
+}
@@ -0,0 +1,175 @@
+// Package ucodetest provides an in-memory stand-in for the Ucode API.
+//
+// The fake server implements items, files and function invocation endpoints
+// used by the SDK, so code built on it can run without a real project:
+//
+//	srv := ucodetest.NewServer()
+//	defer srv.Close()
+//
+//	srv.Seed("order", map[string]any{"guid": "1", "status": "new"})
+//	list, _, err := srv.SDK().Items("order").GetList().Exec()
+package ucodetest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	sdk "github.com/ucode-io/ucode_sdk"
+)
+
+// FunctionHandler answers invocations of a function registered with HandleFunction
+type FunctionHandler func(req sdk.Request) (any, error)
+
+type Server struct {
+	*httptest.Server
+
+	mu          sync.Mutex
+	collections map[string]*collection
+	files       []sdk.FileObject
+	functions   map[string]FunctionHandler
+	rejected    map[string]int
+	nextID      int
+}
+
+type collection struct {
+	order []string
+	items map[string]map[string]any
+}
+
+// NewServer starts a fake Ucode API on a local port
+func NewServer() *Server {
+	s := &Server{
+		collections: map[string]*collection{},
+		functions:   map[string]FunctionHandler{},
+		rejected:    map[string]int{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v2/items/", s.handleItems)
+	mux.HandleFunc("/v1/files", s.handleFiles)
+	mux.HandleFunc("/v1/files/", s.handleFiles)
+	mux.HandleFunc("/v1/invoke_function/", s.handleFunction)
+
+	s.Server = httptest.NewServer(mux)
+
+	return s
+}
+
+// Config returns an SDK config pointing to the fake server
+func (s *Server) Config() *sdk.Config {
+	return &sdk.Config{
+		BaseURL:     s.URL,
+		BaseAuthUrl: s.URL,
+		AppId:       "ucodetest",
+		ProjectId:   "ucodetest",
+	}
+}
+
+func (s *Server) SDK() sdk.UcodeApis {
+	return sdk.New(s.Config())
+}
+
+// Seed adds items to collection, items without guid get a generated one
+func (s *Server) Seed(collection string, items ...map[string]any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, item := range items {
+		s.createItem(collection, item)
+	}
+}
+
+// RejectWrites makes creates, updates and deletes in collection fail with
+// statusCode, like a server refusing the write. Reads keep working, a zero
+// statusCode accepts writes again.
+func (s *Server) RejectWrites(collection string, statusCode int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if statusCode == 0 {
+		delete(s.rejected, collection)
+		return
+	}
+
+	s.rejected[collection] = statusCode
+}
+
+// Items returns copies of all items of collection in creation order
+func (s *Server) Items(collection string) []map[string]any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var (
+		c     = s.collection(collection)
+		items = make([]map[string]any, 0, len(c.order))
+	)
+
+	for _, guid := range c.order {
+		items = append(items, copyItem(c.items[guid]))
+	}
+
+	return items
+}
+
+// Files returns metadata of all uploaded files
+func (s *Server) Files() []sdk.FileObject {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]sdk.FileObject(nil), s.files...)
+}
+
+// HandleFunction registers the function invoked at path. The returned value
+// becomes FunctionResponse.Data, an error a response with status "error".
+func (s *Server) HandleFunction(path string, fn FunctionHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.functions[strings.Trim(path, "/")] = fn
+}
+
+func (s *Server) handleFunction(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/v1/invoke_function/"), "/")
+
+	s.mu.Lock()
+	fn, ok := s.functions[path]
+	s.mu.Unlock()
+
+	if !ok || r.Method != http.MethodPost {
+		writeJSON(w, http.StatusNotFound, sdk.FunctionResponse{Status: "error", Description: fmt.Sprintf("function %s not found", path)})
+		return
+	}
+
+	var req sdk.Request
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, sdk.FunctionResponse{Status: "error", Description: err.Error()})
+		return
+	}
+
+	data, err := fn(req)
+	if err != nil {
+		writeJSON(w, http.StatusOK, sdk.FunctionResponse{Status: "error", Description: err.Error(), CustomMessage: err.Error()})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, sdk.FunctionResponse{Status: "done", Data: data})
+}
+
+func (s *Server) newID() string {
+	s.nextID++
+	return fmt.Sprintf("00000000-0000-4000-8000-%012d", s.nextID)
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, statusCode int, message string) {
+	writeJSON(w, statusCode, map[string]any{"status": http.StatusText(statusCode), "description": message, "data": map[string]any{}})
+}
@@ -0,0 +1,166 @@
+package ucodetest
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	sdk "github.com/ucode-io/ucode_sdk"
+)
+
+func TestServerItems(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	srv.Seed("order",
+		map[string]any{"guid": "1", "status": "new", "total": 30},
+		map[string]any{"guid": "2", "status": "paid", "total": 10},
+		map[string]any{"guid": "3", "status": "new", "total": 20},
+	)
+
+	var (
+		api    = srv.SDK()
+		orders = api.Items("order")
+	)
+
+	created, _, err := orders.Create(map[string]any{"status": "new", "total": 5}).Exec()
+	assert.NoError(t, err)
+	guid := created.Data.Data["guid"].(string)
+	assert.NotEmpty(t, guid)
+
+	list, _, err := orders.GetList().
+		Filter(map[string]any{"status": "new", "total": map[string]any{"$gte": 10}}).
+		Sort(map[string]any{"total": -1}).
+		Limit(1).
+		Page(2).
+		Exec()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, int(list.Data.Data.Count))
+	assert.Len(t, list.Data.Data.Response, 1)
+	assert.Equal(t, "3", list.Data.Data.Response[0]["guid"])
+
+	updated, _, err := orders.Update(map[string]any{"guid": guid, "status": "paid"}).ExecSingle()
+	assert.NoError(t, err)
+	assert.Equal(t, "paid", updated.Data.Data["status"])
+
+	_, _, err = orders.Update(map[string]any{"objects": []any{
+		map[string]any{"guid": "1", "status": "canceled"},
+		map[string]any{"status": "new", "is_new": true},
+	}}).ExecMultiple()
+	assert.NoError(t, err)
+
+	single, _, err := orders.GetSingle("1").Exec()
+	assert.NoError(t, err)
+	assert.Equal(t, "canceled", single.Data.Data.Response["status"])
+
+	_, err = orders.Delete().Single("2").Exec()
+	assert.NoError(t, err)
+
+	_, err = orders.Delete().Multiple([]string{"1", "3"}).Exec()
+	assert.NoError(t, err)
+
+	items := srv.Items("order")
+	assert.Len(t, items, 2)
+	assert.Equal(t, guid, items[0]["guid"])
+	assert.Nil(t, items[1]["is_new"])
+
+	aggregation, _, err := orders.GetList().Pipelines(map[string]any{}).ExecAggregation()
+	assert.NoError(t, err)
+	assert.Len(t, aggregation.Data.Data.Data, 2)
+}
+
+func TestServerItemErrors(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	var (
+		api       = srv.SDK()
+		statusErr *sdk.StatusError
+	)
+
+	list, _, err := api.Items("order").GetList().Exec()
+	assert.NoError(t, err)
+	assert.Empty(t, list.Data.Data.Response)
+
+	_, _, err = api.Items("order").Update(map[string]any{"guid": "missing"}).CheckStatus(true).ExecSingle()
+	assert.True(t, errors.As(err, &statusErr))
+	assert.Equal(t, http.StatusNotFound, statusErr.StatusCode)
+
+	srv.RejectWrites("order", http.StatusForbidden)
+
+	_, _, err = api.Items("order").Create(map[string]any{"title": "first"}).CheckStatus(true).Exec()
+	assert.True(t, errors.As(err, &statusErr))
+	assert.Equal(t, http.StatusForbidden, statusErr.StatusCode)
+	assert.Empty(t, srv.Items("order"))
+
+	_, _, err = api.Items("order").GetList().Exec()
+	assert.NoError(t, err)
+
+	srv.RejectWrites("order", 0)
+
+	_, _, err = api.Items("order").Create(map[string]any{"title": "first"}).Exec()
+	assert.NoError(t, err)
+}
+
+func TestServerFiles(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "logo.png")
+	assert.NoError(t, os.WriteFile(path, []byte("logo"), 0o644))
+
+	api := srv.SDK()
+
+	created, _, err := api.Files().Upload(path).FolderName("assets").Exec()
+	assert.NoError(t, err)
+	assert.Equal(t, "logo.png", created.Data.Title)
+	assert.Equal(t, 4, created.Data.FileSize)
+	assert.Equal(t, created.Checksums.SHA256, created.Data.SHA256)
+
+	files := srv.Files()
+	assert.Len(t, files, 1)
+	assert.Equal(t, "assets", files[0].Storage)
+
+	signed, _, err := api.Files().SignedURL(created.Data.ID, time.Minute).Exec()
+	assert.NoError(t, err)
+	assert.Contains(t, signed.Data.URL, created.Data.Link+"?expires=")
+
+	_, err = api.Files().Delete(created.Data.ID).Exec()
+	assert.NoError(t, err)
+	assert.Empty(t, srv.Files())
+
+	_, err = api.Files().Delete(created.Data.ID).Exec()
+	assert.Error(t, err)
+}
+
+func TestServerFunctions(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	srv.HandleFunction("/square/", func(req sdk.Request) (any, error) {
+		n, ok := req.Data["n"].(float64)
+		if !ok {
+			return nil, errors.New("n is not a number")
+		}
+		return n * n, nil
+	})
+
+	api := srv.SDK()
+
+	resp, _, err := api.Function("square").Invoke(map[string]any{"n": 3}).Exec()
+	assert.NoError(t, err)
+	assert.Equal(t, "done", resp.Status)
+	assert.Equal(t, float64(9), resp.Data)
+
+	resp, _, err = api.Function("square").Invoke(map[string]any{"n": "three"}).Exec()
+	assert.NoError(t, err)
+	assert.Equal(t, "error", resp.Status)
+	assert.Equal(t, "n is not a number", resp.Description)
+
+	resp, _, _ = api.Function("cube").Invoke(map[string]any{"n": 3}).Exec()
+	assert.Equal(t, "error", resp.Status)
+}
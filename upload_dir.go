@@ -0,0 +1,323 @@
+package ucodesdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const (
+	UploadStatusUploaded = "uploaded"
+	UploadStatusSkipped  = "skipped"
+	UploadStatusFailed   = "failed"
+)
+
+func (f *APIFiles) UploadDir(dir string, opts UploadDirOptions) *UploadDir {
+	return &UploadDir{
+		files: f,
+		dir:   dir,
+		opts:  opts,
+	}
+}
+
+// Context sets the context the uploads run with, files not uploaded when it's canceled are reported as failed
+func (u *UploadDir) Context(ctx context.Context) *UploadDir {
+	u.ctx = ctx
+	return u
+}
+
+func (u *UploadDir) Exec() (UploadDirResponse, Response, error) {
+	var (
+		ctx      = orBackground(u.ctx)
+		response = Response{Status: "done"}
+		result   UploadDirResponse
+		opts     = u.opts
+	)
+
+	if opts.FolderName == "" {
+		opts.FolderName = "Media"
+	}
+
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 4
+	}
+
+	files, err := collectDirFiles(u.dir, opts.Include, opts.Exclude)
+	if err != nil {
+		response.Data = map[string]any{"description": u.dir, "message": "can't read directory", "error": err.Error()}
+		response.Status = "error"
+		return UploadDirResponse{}, response, err
+	}
+
+	manifest, err := readUploadManifest(opts.ManifestPath)
+	if err != nil {
+		response.Data = map[string]any{"description": opts.ManifestPath, "message": "can't read upload manifest", "error": err.Error()}
+		response.Status = "error"
+		return UploadDirResponse{}, response, err
+	}
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		jobs = make(chan int)
+	)
+
+	result.Files = make([]UploadDirFileResult, len(files))
+
+	for i := 0; i < opts.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				rel := files[idx]
+				mu.Lock()
+				entry, ok := manifest.Files[rel]
+				mu.Unlock()
+
+				res, newEntry := u.uploadOne(ctx, rel, opts.FolderName, entry, ok)
+				result.Files[idx] = res
+
+				if res.Status == UploadStatusUploaded {
+					mu.Lock()
+					manifest.Files[rel] = newEntry
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+	for i := range files {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	for _, file := range result.Files {
+		switch file.Status {
+		case UploadStatusUploaded:
+			result.Uploaded++
+		case UploadStatusSkipped:
+			result.Skipped++
+		default:
+			result.Failed++
+		}
+	}
+
+	if opts.ManifestPath != "" && result.Uploaded > 0 {
+		if err := manifest.save(opts.ManifestPath); err != nil {
+			response.Data = map[string]any{"description": opts.ManifestPath, "message": "can't write upload manifest", "error": err.Error()}
+			response.Status = "error"
+			return result, response, err
+		}
+	}
+
+	if result.Failed > 0 {
+		err = fmt.Errorf("%d of %d files failed to upload", result.Failed, len(files))
+		response.Data = map[string]any{"message": "Error while uploading directory", "error": err.Error()}
+		response.Status = "error"
+		return result, response, err
+	}
+
+	return result, response, nil
+}
+
+func (u *UploadDir) uploadOne(ctx context.Context, rel, baseFolder string, entry uploadManifestEntry, inManifest bool) (UploadDirFileResult, uploadManifestEntry) {
+	var (
+		fullPath = filepath.Join(u.dir, filepath.FromSlash(rel))
+		folder   = baseFolder
+		result   = UploadDirFileResult{Path: rel}
+	)
+
+	if dir := path.Dir(rel); dir != "." {
+		folder = path.Join(baseFolder, dir)
+	}
+	result.FolderName = folder
+
+	sums, err := fileChecksums(fullPath)
+	if err != nil {
+		result.Status = UploadStatusFailed
+		result.Error = err.Error()
+		return result, entry
+	}
+	result.Checksum = sums.SHA256
+
+	if inManifest && entry.Checksum == sums.SHA256 && entry.FolderName == folder {
+		result.Status = UploadStatusSkipped
+		result.ID = entry.ID
+		result.Link = entry.Link
+		return result, entry
+	}
+
+	created, _, err := u.files.Upload(fullPath).FolderName(folder).Dedup(u.opts.Dedup).Context(ctx).Exec()
+	if err != nil {
+		result.Status = UploadStatusFailed
+		result.Error = err.Error()
+		return result, entry
+	}
+
+	// the manifest must not skip a file the server didn't store
+	if created.Data.ID == "" {
+		result.Status = UploadStatusFailed
+		result.Error = "server returned no file id"
+		return result, entry
+	}
+
+	result.Status = UploadStatusUploaded
+	result.ID = created.Data.ID
+	result.Link = created.Data.Link
+
+	return result, uploadManifestEntry{
+		Checksum:   sums.SHA256,
+		FolderName: folder,
+		ID:         created.Data.ID,
+		Link:       created.Data.Link,
+	}
+}
+
+// collectDirFiles returns slash separated paths of regular files under dir
+// that pass include and exclude patterns, in lexical order.
+func collectDirFiles(dir string, include, exclude []string) ([]string, error) {
+	var files []string
+
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+
+		if d.IsDir() {
+			if matchAnyGlob(exclude, rel) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		if len(include) > 0 && !matchAnyGlob(include, rel) {
+			return nil
+		}
+
+		if matchAnyGlob(exclude, rel) {
+			return nil
+		}
+
+		files = append(files, rel)
+		return nil
+	})
+
+	return files, err
+}
+
+func matchAnyGlob(patterns []string, rel string) bool {
+	for _, pattern := range patterns {
+		if matchGlob(pattern, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchGlob matches rel against pattern. Patterns without a slash match the
+// base name, "**" matches any number of path segments.
+func matchGlob(pattern, rel string) bool {
+	pattern = strings.TrimPrefix(filepath.ToSlash(pattern), "./")
+
+	if !strings.Contains(pattern, "/") {
+		ok, _ := path.Match(pattern, path.Base(rel))
+		return ok
+	}
+
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(rel, "/"))
+}
+
+func matchSegments(pattern, parts []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(parts); i++ {
+				if matchSegments(pattern[1:], parts[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(parts) == 0 {
+			return false
+		}
+
+		if ok, _ := path.Match(pattern[0], parts[0]); !ok {
+			return false
+		}
+
+		pattern, parts = pattern[1:], parts[1:]
+	}
+
+	return len(parts) == 0
+}
+
+type uploadManifest struct {
+	Files map[string]uploadManifestEntry `json:"files"`
+}
+
+type uploadManifestEntry struct {
+	Checksum   string `json:"checksum"`
+	FolderName string `json:"folder_name"`
+	ID         string `json:"id"`
+	Link       string `json:"link"`
+}
+
+func readUploadManifest(manifestPath string) (*uploadManifest, error) {
+	manifest := &uploadManifest{Files: map[string]uploadManifestEntry{}}
+	if manifestPath == "" {
+		return manifest, nil
+	}
+
+	data, err := os.ReadFile(manifestPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return manifest, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, err
+	}
+
+	if manifest.Files == nil {
+		manifest.Files = map[string]uploadManifestEntry{}
+	}
+
+	return manifest, nil
+}
+
+func (m *uploadManifest) save(manifestPath string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := manifestPath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, manifestPath)
+}
@@ -0,0 +1,140 @@
+package ucodesdk
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUploadDir(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		folders []string
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		_, header, err := r.FormFile("file")
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		mu.Lock()
+		folders = append(folders, r.URL.Query().Get("folder_name"))
+		mu.Unlock()
+
+		var resp CreateFileResponse
+		resp.Status = "CREATED"
+		resp.Data.ID = filepath.Base(header.Filename)
+		resp.Data.Link = "https://cdn.test/" + filepath.Base(header.Filename)
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	writeFile := func(rel, content string) {
+		full := filepath.Join(dir, filepath.FromSlash(rel))
+		assert.NoError(t, os.MkdirAll(filepath.Dir(full), 0o755))
+		assert.NoError(t, os.WriteFile(full, []byte(content), 0o644))
+	}
+
+	writeFile("logo.png", "logo")
+	writeFile("images/icons/add.png", "add")
+	writeFile("images/readme.txt", "readme")
+	writeFile("tmp/cache.png", "cache")
+
+	var (
+		api  = New(&Config{BaseURL: server.URL})
+		opts = UploadDirOptions{
+			FolderName:   "assets",
+			Include:      []string{"*.png"},
+			Exclude:      []string{"tmp/**"},
+			Concurrency:  2,
+			ManifestPath: filepath.Join(t.TempDir(), "manifest.json"),
+		}
+	)
+
+	result, response, err := api.Files().UploadDir(dir, opts).Exec()
+	assert.NoError(t, err)
+	assert.Equal(t, "done", response.Status)
+	assert.Equal(t, 2, result.Uploaded)
+	assert.Equal(t, 0, result.Skipped)
+	assert.Equal(t, "images/icons/add.png", result.Files[0].Path)
+	assert.Equal(t, "add.png", result.Files[0].ID)
+
+	sort.Strings(folders)
+	assert.Equal(t, []string{"assets", "assets/images/icons"}, folders)
+
+	// second run skips unchanged files
+	writeFile("logo.png", "new logo")
+
+	result, _, err = api.Files().UploadDir(dir, opts).Exec()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, result.Uploaded)
+	assert.Equal(t, 1, result.Skipped)
+	assert.Equal(t, UploadStatusSkipped, result.Files[0].Status)
+	assert.Equal(t, "https://cdn.test/add.png", result.Files[0].Link)
+}
+
+func TestUploadDirServerError(t *testing.T) {
+	var failing atomic.Bool
+	failing.Store(true)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]any{"status": "error", "description": "storage is down"})
+			return
+		}
+
+		var resp CreateFileResponse
+		resp.Data.ID = "logo-id"
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "logo.png"), []byte("logo"), 0o644))
+
+	var (
+		api  = New(&Config{BaseURL: server.URL})
+		opts = UploadDirOptions{ManifestPath: filepath.Join(t.TempDir(), "manifest.json")}
+	)
+
+	result, response, err := api.Files().UploadDir(dir, opts).Exec()
+	assert.Error(t, err)
+	assert.Equal(t, "error", response.Status)
+	assert.Equal(t, 1, result.Failed)
+	assert.Equal(t, UploadStatusFailed, result.Files[0].Status)
+	assert.Contains(t, result.Files[0].Error, "status 500")
+
+	// the failed file isn't in the manifest, the next run uploads it
+	failing.Store(false)
+
+	result, _, err = api.Files().UploadDir(dir, opts).Exec()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, result.Uploaded)
+	assert.Equal(t, "logo-id", result.Files[0].ID)
+}
+
+func TestMatchGlob(t *testing.T) {
+	assert.True(t, matchGlob("*.png", "a/b/c.png"))
+	assert.True(t, matchGlob("a/**/*.png", "a/c.png"))
+	assert.True(t, matchGlob("a/**/*.png", "a/b/d/c.png"))
+	assert.True(t, matchGlob("tmp/**", "tmp"))
+	assert.False(t, matchGlob("a/*.png", "a/b/c.png"))
+	assert.False(t, matchGlob("*.png", "a/b/c.jpg"))
+}
@@ -0,0 +1,336 @@
+package ucodesdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/spf13/cast"
+)
+
+// ItemEventType is the operation that changed an item
+type ItemEventType string
+
+const (
+	ItemCreated ItemEventType = "create"
+	ItemUpdated ItemEventType = "update"
+	ItemDeleted ItemEventType = "delete"
+)
+
+// ErrEventsDropped is reported by ItemWatcher.Err when events arrived while the events channel was full
+var ErrEventsDropped = errors.New("item events dropped, the events channel is full")
+
+// ItemsTopic returns the MQTT topic change events of collection are published to.
+// Use "+" as action to match every operation.
+//
+// The topic scheme ucode/{project_id}/items/{collection}/{action} is the
+// contract with the platform's event publisher. Deployments that publish
+// elsewhere can subscribe to their own topic with WatchItems.Topic.
+func ItemsTopic(projectID, collection, action string) string {
+	return fmt.Sprintf("ucode/%s/items/%s/%s", projectID, collection, action)
+}
+
+/*
+ItemEvent is a change event published to ItemsTopic:
+
+	{
+		"action": "update",            // create, update or delete
+		"table_slug": "order",
+		"object_id": "guid",
+		"data": {...},                 // values after the change, empty for delete
+		"previous": {...},             // values before update or delete
+		"user_id": "...",
+		"timestamp": "2024-01-01T00:00:00Z"
+	}
+*/
+type ItemEvent struct {
+	Type       ItemEventType  `json:"action"`
+	Collection string         `json:"table_slug"`
+	ObjectID   string         `json:"object_id"`
+	Data       map[string]any `json:"data"`
+	Previous   map[string]any `json:"previous,omitempty"`
+	UserID     string         `json:"user_id,omitempty"`
+	Timestamp  time.Time      `json:"timestamp"`
+	Topic      string         `json:"-"`
+}
+
+// Decode decodes Data into v by JSON field names
+func (e ItemEvent) Decode(v any) error {
+	body, err := json.Marshal(e.Data)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, v)
+}
+
+// DecodePrevious decodes Previous into v by JSON field names
+func (e ItemEvent) DecodePrevious(v any) error {
+	body, err := json.Marshal(e.Previous)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, v)
+}
+
+// ITEMS WATCH
+func (a *APIItem) Watch(ctx context.Context, filter map[string]any) *WatchItems {
+	manager := a.mqtt
+	if manager == nil {
+		manager = newMQTTManager(a.config)
+	}
+
+	return &WatchItems{
+		collection: a.collection,
+		config:     a.config,
+		mqtt:       manager,
+		ctx:        ctx,
+		filter:     filter,
+		buffer:     64,
+		qos:        1,
+	}
+}
+
+// Events limits the watch to the given operations, default all
+func (w *WatchItems) Events(types ...ItemEventType) *WatchItems {
+	w.events = types
+	return w
+}
+
+// Buffer sets the capacity of the events channel, default 64. Events arriving
+// while the channel is full are dropped, see ItemWatcher.Dropped.
+func (w *WatchItems) Buffer(size int) *WatchItems {
+	if size >= 0 {
+		w.buffer = size
+	}
+	return w
+}
+
+func (w *WatchItems) QoS(qos byte) *WatchItems {
+	w.qos = qos
+	return w
+}
+
+// Topic sets the MQTT topic to subscribe to, default ItemsTopic(ProjectId, collection, "+")
+func (w *WatchItems) Topic(topic string) *WatchItems {
+	w.topic = topic
+	return w
+}
+
+// OnEvent delivers events to handler instead of the events channel. handler
+// runs on the MQTT client's goroutine, it must not block.
+func (w *WatchItems) OnEvent(handler func(ItemEvent)) *WatchItems {
+	w.handler = handler
+	return w
+}
+
+/*
+Exec subscribes to the collection changes on the shared MQTT connection, see ConnectToMQTT.
+The watch stops when ctx is done or Close is called. Subscriptions are restored
+after the connection is reestablished.
+*/
+func (w *WatchItems) Exec() (*ItemWatcher, error) {
+	ctx := w.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+
+	watcher := &ItemWatcher{
+		filter: w.filter,
+		types:  w.events,
+		ctx:    ctx,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+
+	if w.handler != nil {
+		watcher.handler = w.handler
+	} else {
+		watcher.events = make(chan ItemEvent, w.buffer)
+		watcher.handler = watcher.send
+	}
+
+	topic := w.topic
+	if topic == "" {
+		topic = ItemsTopic(w.config.ProjectId, w.collection, "+")
+	}
+
+	unsubscribe, err := w.mqtt.subscribe(ctx, topic, w.qos, func(_ mqtt.Client, msg mqtt.Message) {
+		watcher.receive(msg)
+	})
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	watcher.unsubscribe = unsubscribe
+
+	go watcher.run()
+
+	return watcher, nil
+}
+
+type ItemWatcher struct {
+	filter      map[string]any
+	types       []ItemEventType
+	handler     func(ItemEvent)
+	events      chan ItemEvent
+	unsubscribe func() error
+
+	// sendMu keeps the events channel open while a handler sends to it
+	sendMu sync.RWMutex
+	closed bool
+
+	dropped atomic.Uint64
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu  sync.Mutex
+	err error
+}
+
+// Events returns the channel events are delivered to, it is closed when the watch stops.
+// It is nil if OnEvent is used.
+func (w *ItemWatcher) Events() <-chan ItemEvent {
+	return w.events
+}
+
+// Dropped returns the number of events dropped because the events channel was full
+func (w *ItemWatcher) Dropped() uint64 {
+	return w.dropped.Load()
+}
+
+// Err returns the last unsubscribe, decoding or ErrEventsDropped error
+func (w *ItemWatcher) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+// Done is closed when the watch stops
+func (w *ItemWatcher) Done() <-chan struct{} {
+	return w.done
+}
+
+func (w *ItemWatcher) Close() {
+	w.cancel()
+	<-w.done
+}
+
+func (w *ItemWatcher) run() {
+	<-w.ctx.Done()
+
+	if err := w.unsubscribe(); err != nil {
+		w.setErr(err)
+	}
+
+	if w.events != nil {
+		w.sendMu.Lock()
+		w.closed = true
+		close(w.events)
+		w.sendMu.Unlock()
+	}
+	close(w.done)
+}
+
+// send delivers event to the events channel without blocking the MQTT
+// client, which would stall every other subscription of the connection
+func (w *ItemWatcher) send(event ItemEvent) {
+	w.sendMu.RLock()
+	defer w.sendMu.RUnlock()
+
+	if w.closed {
+		return
+	}
+
+	select {
+	case w.events <- event:
+	default:
+		w.dropped.Add(1)
+		w.setErr(ErrEventsDropped)
+	}
+}
+
+func (w *ItemWatcher) receive(msg mqtt.Message) {
+	if w.ctx.Err() != nil {
+		return
+	}
+
+	var event ItemEvent
+	if err := json.Unmarshal(msg.Payload(), &event); err != nil {
+		w.setErr(fmt.Errorf("decoding item event from %s: %w", msg.Topic(), err))
+		return
+	}
+	event.Topic = msg.Topic()
+
+	if !w.matches(event) {
+		return
+	}
+
+	w.handler(event)
+}
+
+func (w *ItemWatcher) matches(event ItemEvent) bool {
+	if len(w.types) > 0 {
+		found := false
+		for _, t := range w.types {
+			if t == event.Type {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	data := event.Data
+	if len(data) == 0 {
+		data = event.Previous
+	}
+
+	return matchItemFilter(w.filter, data)
+}
+
+func (w *ItemWatcher) setErr(err error) {
+	w.mu.Lock()
+	w.err = err
+	w.mu.Unlock()
+}
+
+// matchItemFilter reports whether data has every field of filter, a slice value matches any of its elements
+func matchItemFilter(filter, data map[string]any) bool {
+	for field, expected := range filter {
+		value, ok := data[field]
+		if !ok {
+			return false
+		}
+
+		if rv := reflect.ValueOf(expected); rv.Kind() == reflect.Slice {
+			found := false
+			for i := 0; i < rv.Len(); i++ {
+				if cast.ToString(rv.Index(i).Interface()) == cast.ToString(value) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+			continue
+		}
+
+		if cast.ToString(expected) != cast.ToString(value) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,77 @@
+package ucodesdk
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testMessage struct {
+	topic   string
+	payload []byte
+}
+
+func (m testMessage) Duplicate() bool   { return false }
+func (m testMessage) Qos() byte         { return 1 }
+func (m testMessage) Retained() bool    { return false }
+func (m testMessage) Topic() string     { return m.topic }
+func (m testMessage) MessageID() uint16 { return 1 }
+func (m testMessage) Payload() []byte   { return m.payload }
+func (m testMessage) Ack()              {}
+
+func TestItemWatcherReceive(t *testing.T) {
+	var received []ItemEvent
+
+	watcher := &ItemWatcher{
+		ctx:     context.Background(),
+		filter:  map[string]any{"status": []string{"new", "paid"}},
+		types:   []ItemEventType{ItemCreated, ItemDeleted},
+		handler: func(event ItemEvent) { received = append(received, event) },
+	}
+
+	topic := ItemsTopic("project", "order", "create")
+	assert.Equal(t, "ucode/project/items/order/create", topic)
+
+	watcher.receive(testMessage{topic, []byte(`{"action":"create","table_slug":"order","object_id":"1","data":{"status":"new","total":10}}`)})
+	watcher.receive(testMessage{topic, []byte(`{"action":"create","table_slug":"order","object_id":"2","data":{"status":"draft"}}`)})
+	watcher.receive(testMessage{topic, []byte(`{"action":"update","table_slug":"order","object_id":"1","data":{"status":"paid"}}`)})
+	watcher.receive(testMessage{topic, []byte(`{"action":"delete","table_slug":"order","object_id":"3","previous":{"status":"paid"}}`)})
+	watcher.receive(testMessage{topic, []byte(`not json`)})
+
+	assert.Len(t, received, 2)
+	assert.Equal(t, "1", received[0].ObjectID)
+	assert.Equal(t, topic, received[0].Topic)
+	assert.Equal(t, ItemDeleted, received[1].Type)
+	assert.Error(t, watcher.Err())
+
+	var order struct {
+		Status string  `json:"status"`
+		Total  float64 `json:"total"`
+	}
+	assert.NoError(t, received[0].Decode(&order))
+	assert.Equal(t, 10.0, order.Total)
+}
+
+func TestItemWatcherDropsWhenFull(t *testing.T) {
+	watcher := &ItemWatcher{
+		ctx:    context.Background(),
+		events: make(chan ItemEvent, 1),
+	}
+	watcher.handler = watcher.send
+
+	topic := ItemsTopic("project", "order", "create")
+	for _, id := range []string{"1", "2", "3"} {
+		// a full channel must not block the MQTT client
+		watcher.receive(testMessage{topic, []byte(`{"action":"create","object_id":"` + id + `"}`)})
+	}
+
+	assert.Equal(t, "1", (<-watcher.Events()).ObjectID)
+	assert.Equal(t, uint64(2), watcher.Dropped())
+	assert.ErrorIs(t, watcher.Err(), ErrEventsDropped)
+}
+
+func TestWatchRequiresBroker(t *testing.T) {
+	_, err := New(&Config{}).Items("order").Watch(context.Background(), nil).Exec()
+	assert.Error(t, err)
+}
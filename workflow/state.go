@@ -0,0 +1,195 @@
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	sdk "github.com/ucode-io/ucode_sdk"
+)
+
+// State holds the workflow input and outputs of finished steps
+type State struct {
+	mu      sync.RWMutex
+	ucode   sdk.UcodeApis
+	input   map[string]any
+	outputs map[string]any
+	undo    map[string]any
+}
+
+func newState(ucode sdk.UcodeApis, input map[string]any) *State {
+	if input == nil {
+		input = map[string]any{}
+	}
+
+	return &State{
+		ucode:   ucode,
+		input:   input,
+		outputs: map[string]any{},
+		undo:    map[string]any{},
+	}
+}
+
+func (s *State) SDK() sdk.UcodeApis {
+	return s.ucode
+}
+
+func (s *State) Input() map[string]any {
+	return s.input
+}
+
+// Output returns what step returned, nil if it didn't run yet
+func (s *State) Output(step string) any {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.outputs[step]
+}
+
+func (s *State) setOutput(step string, output any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.outputs[step] = output
+}
+
+// setUndo stores what a step needs to compensate itself besides its output
+func (s *State) setUndo(step string, value any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.undo[step] = value
+}
+
+func (s *State) undoValue(step string) any {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.undo[step]
+}
+
+func (s *State) snapshot() map[string]any {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	outputs := make(map[string]any, len(s.outputs))
+	for step, output := range s.outputs {
+		outputs[step] = output
+	}
+	return outputs
+}
+
+/*
+Value resolves a dotted path. The first segment is a step name or "input",
+the rest are keys of maps or struct fields by their JSON names:
+
+	s.Value("create_order.guid")
+	s.Value("pricing.total")
+	s.Value("input.customer_id")
+*/
+func (s *State) Value(path string) (any, error) {
+	parts := strings.Split(path, ".")
+
+	var value any
+	if parts[0] == "input" {
+		value = s.input
+	} else {
+		s.mu.RLock()
+		output, ok := s.outputs[parts[0]]
+		s.mu.RUnlock()
+
+		if !ok {
+			return nil, fmt.Errorf("step %q has no output", parts[0])
+		}
+		value = output
+	}
+
+	for _, key := range parts[1:] {
+		m, err := asMap(value)
+		if err != nil {
+			return nil, fmt.Errorf("resolving %q: %w", path, err)
+		}
+
+		next, ok := m[key]
+		if !ok {
+			return nil, fmt.Errorf("resolving %q: no key %q", path, key)
+		}
+		value = next
+	}
+
+	return value, nil
+}
+
+// String resolves path like Value and formats the result, empty if it can't be resolved
+func (s *State) String(path string) string {
+	value, err := s.Value(path)
+	if err != nil || value == nil {
+		return ""
+	}
+	return fmt.Sprint(value)
+}
+
+func asMap(value any) (map[string]any, error) {
+	if m, ok := value.(map[string]any); ok {
+		return m, nil
+	}
+
+	body, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var m map[string]any
+	if err := json.Unmarshal(body, &m); err != nil {
+		return nil, fmt.Errorf("%T is not an object", value)
+	}
+
+	return m, nil
+}
+
+// Ref is a placeholder in Data resolved with State.Value
+type Ref string
+
+// Data returns an input function building a map from template. Ref values,
+// also inside nested maps and slices, are replaced with values from the state.
+//
+//	workflow.Data(map[string]any{"order_id": workflow.Ref("create_order.guid"), "status": "priced"})
+func Data(template map[string]any) Input {
+	return func(s *State) (map[string]any, error) {
+		resolved, err := resolve(s, template)
+		if err != nil {
+			return nil, err
+		}
+		return resolved.(map[string]any), nil
+	}
+}
+
+func resolve(s *State, value any) (any, error) {
+	switch v := value.(type) {
+	case Ref:
+		return s.Value(string(v))
+	case map[string]any:
+		resolved := make(map[string]any, len(v))
+		for key, elem := range v {
+			r, err := resolve(s, elem)
+			if err != nil {
+				return nil, err
+			}
+			resolved[key] = r
+		}
+		return resolved, nil
+	case []any:
+		resolved := make([]any, len(v))
+		for i, elem := range v {
+			r, err := resolve(s, elem)
+			if err != nil {
+				return nil, err
+			}
+			resolved[i] = r
+		}
+		return resolved, nil
+	}
+
+	return value, nil
+}
@@ -0,0 +1,184 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cast"
+)
+
+// Input builds the data of a step from the state, see Data
+type Input func(*State) (map[string]any, error)
+
+// CreateItem creates an object in collection. The output is the created
+// object, compensation deletes it.
+func CreateItem(name, collection string, input Input) Step {
+	return Step{
+		Name: name,
+		Run: func(ctx context.Context, s *State) (any, error) {
+			data, err := input(s)
+			if err != nil {
+				return nil, err
+			}
+
+			created, _, err := s.SDK().Items(collection).Create(data).CheckStatus(true).Context(ctx).Exec()
+			if err != nil {
+				return nil, err
+			}
+
+			return created.Data.Data, nil
+		},
+		Compensate: func(ctx context.Context, s *State, output any) error {
+			guid := cast.ToString(cast.ToStringMap(output)["guid"])
+			if guid == "" {
+				return fmt.Errorf("created object has no guid")
+			}
+
+			_, err := s.SDK().Items(collection).Delete().Single(guid).CheckStatus(true).Context(ctx).Exec()
+			return err
+		},
+	}
+}
+
+// UpdateItem updates an object of collection, input must contain its guid.
+// The output is the updated object, compensation restores the previous
+// values of the updated fields.
+func UpdateItem(name, collection string, input Input) Step {
+	return Step{
+		Name: name,
+		Run: func(ctx context.Context, s *State) (any, error) {
+			data, err := input(s)
+			if err != nil {
+				return nil, err
+			}
+
+			guid := cast.ToString(data["guid"])
+			if guid == "" {
+				return nil, fmt.Errorf("update data has no guid")
+			}
+
+			// a retry after an applied update would read the new values,
+			// so the previous ones are captured before the first attempt only
+			if s.undoValue(name) == nil {
+				current, _, err := s.SDK().Items(collection).GetSingle(guid).CheckStatus(true).Context(ctx).Exec()
+				if err != nil {
+					return nil, err
+				}
+
+				previous := map[string]any{"guid": guid}
+				for key := range data {
+					previous[key] = current.Data.Data.Response[key]
+				}
+				s.setUndo(name, previous)
+			}
+
+			updated, _, err := s.SDK().Items(collection).Update(data).CheckStatus(true).Context(ctx).ExecSingle()
+			if err != nil {
+				return nil, err
+			}
+
+			return updated.Data.Data, nil
+		},
+		Compensate: func(ctx context.Context, s *State, output any) error {
+			previous := cast.ToStringMap(s.undoValue(name))
+			if len(previous) == 0 {
+				return nil
+			}
+
+			_, _, err := s.SDK().Items(collection).Update(previous).CheckStatus(true).Context(ctx).ExecSingle()
+			return err
+		},
+	}
+}
+
+// DeleteItem deletes the object of collection with the guid returned by guid.
+// Compensation creates the deleted object again.
+func DeleteItem(name, collection string, guid func(*State) (string, error)) Step {
+	return Step{
+		Name: name,
+		Run: func(ctx context.Context, s *State) (any, error) {
+			id, err := guid(s)
+			if err != nil {
+				return nil, err
+			}
+
+			current, _, err := s.SDK().Items(collection).GetSingle(id).CheckStatus(true).Context(ctx).Exec()
+			if err != nil {
+				return nil, err
+			}
+			deleted := current.Data.Data.Response
+
+			_, err = s.SDK().Items(collection).Delete().Single(id).CheckStatus(true).Context(ctx).Exec()
+			if err != nil {
+				return nil, err
+			}
+
+			return deleted, nil
+		},
+		Compensate: func(ctx context.Context, s *State, output any) error {
+			deleted := cast.ToStringMap(output)
+			if len(deleted) == 0 {
+				return nil
+			}
+
+			_, _, err := s.SDK().Items(collection).Create(deleted).CheckStatus(true).Context(ctx).Exec()
+			return err
+		},
+	}
+}
+
+// InvokeFunction invokes the function at path. The output is FunctionResponse.Data,
+// a response with status "error" fails the step with *sdk.FunctionError.
+// It has no compensation, add one with WithCompensation.
+func InvokeFunction(name, path string, input Input) Step {
+	return Step{
+		Name: name,
+		Run: func(ctx context.Context, s *State) (any, error) {
+			data, err := input(s)
+			if err != nil {
+				return nil, err
+			}
+
+			resp, _, err := s.SDK().Function(path).Invoke(data).Context(ctx).Exec()
+			if err != nil {
+				return nil, err
+			}
+
+			if err := resp.Err(path); err != nil {
+				return nil, err
+			}
+
+			return resp.Data, nil
+		},
+	}
+}
+
+// UploadFile uploads the local file returned by path. The output contains
+// "id" and "link" of the file, compensation deletes it.
+func UploadFile(name string, path func(*State) (string, error)) Step {
+	return Step{
+		Name: name,
+		Run: func(ctx context.Context, s *State) (any, error) {
+			filePath, err := path(s)
+			if err != nil {
+				return nil, err
+			}
+
+			created, _, err := s.SDK().Files().Upload(filePath).Context(ctx).Exec()
+			if err != nil {
+				return nil, err
+			}
+
+			return asMap(created.Data)
+		},
+		Compensate: func(ctx context.Context, s *State, output any) error {
+			id := cast.ToString(cast.ToStringMap(output)["id"])
+			if id == "" {
+				return fmt.Errorf("uploaded file has no id")
+			}
+
+			_, err := s.SDK().Files().Delete(id).Context(ctx).Exec()
+			return err
+		},
+	}
+}
@@ -0,0 +1,356 @@
+// Package workflow runs chains of SDK operations as sagas.
+//
+// Steps declare their inputs from outputs of earlier steps, run in order or
+// as a DAG, are retried on failure and, if the workflow fails, completed
+// steps are compensated in reverse order:
+//
+//	result, err := workflow.New(ucode).
+//		Step(workflow.CreateItem("order", "order", workflow.Data(map[string]any{"status": "new"}))).
+//		Step(workflow.InvokeFunction("pricing", "calculate-price", workflow.Data(map[string]any{
+//			"order_id": workflow.Ref("order.guid"),
+//		})).WithRetries(3, time.Second)).
+//		Step(workflow.UpdateItem("priced", "order", workflow.Data(map[string]any{
+//			"guid":  workflow.Ref("order.guid"),
+//			"total": workflow.Ref("pricing.total"),
+//		}))).
+//		Step(workflow.UploadFile("invoice", func(s *workflow.State) (string, error) {
+//			return renderInvoice(s.String("order.guid"))
+//		})).
+//		Run(ctx, nil)
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	sdk "github.com/ucode-io/ucode_sdk"
+)
+
+type Step struct {
+	Name string
+	// DependsOn lists steps that must finish first. Used by DAG workflows,
+	// sequential workflows run steps in the order they were added.
+	DependsOn []string
+	// Run performs the step, its result is stored as the step output
+	Run func(ctx context.Context, s *State) (any, error)
+	// Compensate undoes a completed step when a later step fails
+	Compensate func(ctx context.Context, s *State, output any) error
+	// Retries is the number of additional attempts after Run fails
+	Retries int
+	// RetryDelay is multiplied by the attempt number between attempts
+	RetryDelay time.Duration
+}
+
+func (s Step) After(steps ...string) Step {
+	s.DependsOn = append(append([]string(nil), s.DependsOn...), steps...)
+	return s
+}
+
+func (s Step) WithRetries(retries int, delay time.Duration) Step {
+	s.Retries = retries
+	s.RetryDelay = delay
+	return s
+}
+
+func (s Step) WithCompensation(compensate func(ctx context.Context, s *State, output any) error) Step {
+	s.Compensate = compensate
+	return s
+}
+
+type Workflow struct {
+	ucode       sdk.UcodeApis
+	steps       []Step
+	dag         bool
+	concurrency int
+}
+
+func New(ucode sdk.UcodeApis) *Workflow {
+	return &Workflow{ucode: ucode, concurrency: 4}
+}
+
+func (w *Workflow) Step(step Step) *Workflow {
+	w.steps = append(w.steps, step)
+	return w
+}
+
+// DAG runs every step as soon as the steps in its DependsOn finished,
+// up to concurrency steps at a time
+func (w *Workflow) DAG(concurrency int) *Workflow {
+	w.dag = true
+	if concurrency > 0 {
+		w.concurrency = concurrency
+	}
+	return w
+}
+
+type Result struct {
+	Outputs map[string]any
+	// Completed steps in the order they finished
+	Completed []string
+	// Failed is the step that failed the workflow
+	Failed string
+	// Compensated steps in the order they were undone
+	Compensated []string
+	// CompensationErrors of steps that couldn't be undone
+	CompensationErrors map[string]error
+}
+
+// StepError is returned by Run when a step fails after all retries
+type StepError struct {
+	Step     string
+	Attempts int
+	Err      error
+}
+
+func (e *StepError) Error() string {
+	return fmt.Sprintf("workflow step %s failed after %d attempts: %v", e.Step, e.Attempts, e.Err)
+}
+
+func (e *StepError) Unwrap() error {
+	return e.Err
+}
+
+/*
+Run executes the workflow with input available as State.Input.
+
+If a step fails the returned error is a *StepError, completed steps with a
+Compensate function are undone in reverse completion order and failures of
+compensations are joined to the error.
+*/
+func (w *Workflow) Run(ctx context.Context, input map[string]any) (Result, error) {
+	if err := w.validate(); err != nil {
+		return Result{}, err
+	}
+
+	var (
+		state  = newState(w.ucode, input)
+		result = Result{CompensationErrors: map[string]error{}}
+		err    error
+	)
+
+	if w.dag {
+		result.Completed, result.Failed, err = w.runDAG(ctx, state)
+	} else {
+		result.Completed, result.Failed, err = w.runSequential(ctx, state)
+	}
+
+	if err != nil {
+		w.compensate(context.WithoutCancel(ctx), state, &result)
+
+		var errs []error
+		for _, step := range result.Completed {
+			if compErr, ok := result.CompensationErrors[step]; ok {
+				errs = append(errs, fmt.Errorf("compensating %s: %w", step, compErr))
+			}
+		}
+		err = errors.Join(append([]error{err}, errs...)...)
+	}
+
+	result.Outputs = state.snapshot()
+
+	return result, err
+}
+
+func (w *Workflow) validate() error {
+	names := map[string]bool{}
+	for _, step := range w.steps {
+		if step.Name == "" || step.Run == nil {
+			return fmt.Errorf("workflow step %q must have a name and Run", step.Name)
+		}
+		if step.Name == "input" || names[step.Name] {
+			return fmt.Errorf("workflow step name %q is reserved or used twice", step.Name)
+		}
+		names[step.Name] = true
+	}
+
+	if !w.dag {
+		return nil
+	}
+
+	for _, step := range w.steps {
+		for _, dep := range step.DependsOn {
+			if !names[dep] {
+				return fmt.Errorf("workflow step %s depends on unknown step %s", step.Name, dep)
+			}
+		}
+	}
+
+	// Kahn's algorithm, every step must be reachable
+	var (
+		indegree = map[string]int{}
+		next     = map[string][]string{}
+		queue    []string
+		visited  int
+	)
+
+	for _, step := range w.steps {
+		indegree[step.Name] = len(step.DependsOn)
+		for _, dep := range step.DependsOn {
+			next[dep] = append(next[dep], step.Name)
+		}
+		if len(step.DependsOn) == 0 {
+			queue = append(queue, step.Name)
+		}
+	}
+
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		visited++
+
+		for _, n := range next[name] {
+			indegree[n]--
+			if indegree[n] == 0 {
+				queue = append(queue, n)
+			}
+		}
+	}
+
+	if visited != len(w.steps) {
+		return fmt.Errorf("workflow steps have a dependency cycle")
+	}
+
+	return nil
+}
+
+func (w *Workflow) runSequential(ctx context.Context, state *State) ([]string, string, error) {
+	var completed []string
+
+	for _, step := range w.steps {
+		if err := runStep(ctx, step, state); err != nil {
+			return completed, step.Name, err
+		}
+		completed = append(completed, step.Name)
+	}
+
+	return completed, "", nil
+}
+
+func (w *Workflow) runDAG(ctx context.Context, state *State) ([]string, string, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type done struct {
+		name string
+		err  error
+	}
+
+	var (
+		completed []string
+		failed    string
+		firstErr  error
+		pending   = map[string]Step{}
+		finished  = map[string]bool{}
+		running   int
+		results   = make(chan done)
+		wg        sync.WaitGroup
+	)
+
+	for _, step := range w.steps {
+		pending[step.Name] = step
+	}
+
+	for {
+		// start every ready step while nothing failed
+		for _, step := range w.steps {
+			if firstErr != nil || running >= w.concurrency {
+				break
+			}
+			if _, ok := pending[step.Name]; !ok || !dependenciesFinished(step, finished) {
+				continue
+			}
+
+			delete(pending, step.Name)
+			running++
+			wg.Add(1)
+			go func(step Step) {
+				defer wg.Done()
+				results <- done{name: step.Name, err: runStep(ctx, step, state)}
+			}(step)
+		}
+
+		if running == 0 {
+			break
+		}
+
+		res := <-results
+		running--
+
+		if res.err != nil {
+			if firstErr == nil {
+				firstErr, failed = res.err, res.name
+				cancel()
+			}
+			continue
+		}
+
+		finished[res.name] = true
+		completed = append(completed, res.name)
+	}
+
+	wg.Wait()
+
+	return completed, failed, firstErr
+}
+
+func dependenciesFinished(step Step, finished map[string]bool) bool {
+	for _, dep := range step.DependsOn {
+		if !finished[dep] {
+			return false
+		}
+	}
+	return true
+}
+
+func runStep(ctx context.Context, step Step, state *State) error {
+	var err error
+
+	for attempt := 1; attempt <= step.Retries+1; attempt++ {
+		if attempt > 1 && step.RetryDelay > 0 {
+			timer := time.NewTimer(step.RetryDelay * time.Duration(attempt-1))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return &StepError{Step: step.Name, Attempts: attempt - 1, Err: ctx.Err()}
+			case <-timer.C:
+			}
+		}
+
+		var output any
+		output, err = step.Run(ctx, state)
+		if err == nil {
+			state.setOutput(step.Name, output)
+			return nil
+		}
+
+		if ctx.Err() != nil {
+			return &StepError{Step: step.Name, Attempts: attempt, Err: err}
+		}
+	}
+
+	return &StepError{Step: step.Name, Attempts: step.Retries + 1, Err: err}
+}
+
+func (w *Workflow) compensate(ctx context.Context, state *State, result *Result) {
+	steps := map[string]Step{}
+	for _, step := range w.steps {
+		steps[step.Name] = step
+	}
+
+	for i := len(result.Completed) - 1; i >= 0; i-- {
+		step := steps[result.Completed[i]]
+		if step.Compensate == nil {
+			continue
+		}
+
+		if err := step.Compensate(ctx, state, state.Output(step.Name)); err != nil {
+			result.CompensationErrors[step.Name] = err
+			continue
+		}
+
+		result.Compensated = append(result.Compensated, step.Name)
+	}
+}
@@ -0,0 +1,138 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	sdk "github.com/ucode-io/ucode_sdk"
+	"github.com/ucode-io/ucode_sdk/ucodetest"
+)
+
+func TestWorkflowSaga(t *testing.T) {
+	srv := ucodetest.NewServer()
+	defer srv.Close()
+
+	srv.Seed("customer", map[string]any{"guid": "c1", "orders": 0})
+
+	var pricingCalls int32
+	srv.HandleFunction("pricing", func(req sdk.Request) (any, error) {
+		if atomic.AddInt32(&pricingCalls, 1) < 2 {
+			return nil, errors.New("pricing unavailable")
+		}
+		return map[string]any{"total": 42}, nil
+	})
+	srv.HandleFunction("invoice", func(req sdk.Request) (any, error) {
+		return nil, errors.New("invoice service down")
+	})
+
+	result, err := New(srv.SDK()).
+		Step(CreateItem("order", "order", Data(map[string]any{"customer_id": Ref("input.customer_id")}))).
+		Step(UpdateItem("customer", "customer", Data(map[string]any{"guid": Ref("input.customer_id"), "orders": 1}))).
+		Step(InvokeFunction("pricing", "pricing", Data(map[string]any{"order_id": Ref("order.guid")})).WithRetries(2, 0)).
+		Step(InvokeFunction("invoice", "invoice", Data(map[string]any{"total": Ref("pricing.total")}))).
+		Run(context.Background(), map[string]any{"customer_id": "c1"})
+
+	var stepErr *StepError
+	assert.ErrorAs(t, err, &stepErr)
+	assert.Equal(t, "invoice", stepErr.Step)
+	assert.Equal(t, "invoice", result.Failed)
+	assert.Equal(t, []string{"order", "customer", "pricing"}, result.Completed)
+	assert.Equal(t, []string{"customer", "order"}, result.Compensated)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&pricingCalls))
+
+	assert.Empty(t, srv.Items("order"))
+	assert.Equal(t, float64(0), srv.Items("customer")[0]["orders"])
+}
+
+func TestWorkflowRejectedWrite(t *testing.T) {
+	srv := ucodetest.NewServer()
+	defer srv.Close()
+
+	srv.Seed("order")
+	srv.Seed("customer", map[string]any{"guid": "c1", "orders": 0})
+	srv.RejectWrites("customer", http.StatusForbidden)
+
+	result, err := New(srv.SDK()).
+		Step(CreateItem("order", "order", Data(map[string]any{"customer_id": Ref("input.customer_id")}))).
+		Step(UpdateItem("customer", "customer", Data(map[string]any{"guid": Ref("input.customer_id"), "orders": 1}))).
+		Run(context.Background(), map[string]any{"customer_id": "c1"})
+
+	var statusErr *sdk.StatusError
+	assert.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, http.StatusForbidden, statusErr.StatusCode)
+	assert.Equal(t, "customer", result.Failed)
+	assert.Equal(t, []string{"order"}, result.Compensated)
+
+	assert.Empty(t, srv.Items("order"))
+	assert.Equal(t, 0, srv.Items("customer")[0]["orders"])
+}
+
+func TestWorkflowUpdateRetryKeepsPrevious(t *testing.T) {
+	srv := ucodetest.NewServer()
+	defer srv.Close()
+
+	srv.Seed("customer", map[string]any{"guid": "c1", "orders": 0})
+	srv.HandleFunction("invoice", func(req sdk.Request) (any, error) {
+		return nil, errors.New("invoice service down")
+	})
+
+	// the first update is applied, but its response is lost
+	var updates int32
+	target, _ := url.Parse(srv.URL)
+	upstream := httputil.NewSingleHostReverseProxy(target)
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut && atomic.AddInt32(&updates, 1) == 1 {
+			upstream.ServeHTTP(httptest.NewRecorder(), r)
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		upstream.ServeHTTP(w, r)
+	}))
+	defer proxy.Close()
+
+	config := srv.Config()
+	config.BaseURL = proxy.URL
+
+	result, err := New(sdk.New(config)).
+		Step(UpdateItem("customer", "customer", Data(map[string]any{"guid": "c1", "orders": 1})).WithRetries(1, 0)).
+		Step(InvokeFunction("invoice", "invoice", Data(map[string]any{}))).
+		Run(context.Background(), nil)
+
+	assert.Error(t, err)
+	assert.Equal(t, []string{"customer"}, result.Compensated)
+	assert.Equal(t, float64(0), srv.Items("customer")[0]["orders"])
+}
+
+func TestWorkflowDAG(t *testing.T) {
+	var (
+		order []string
+		run   = func(name string) Step {
+			return Step{Name: name, Run: func(ctx context.Context, s *State) (any, error) {
+				order = append(order, name)
+				return name, nil
+			}}
+		}
+	)
+
+	result, err := New(nil).DAG(1).
+		Step(run("c").After("a", "b")).
+		Step(run("b").After("a")).
+		Step(run("a")).
+		Run(context.Background(), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, order)
+	assert.Equal(t, "b", result.Outputs["b"])
+
+	_, err = New(nil).DAG(1).
+		Step(run("a").After("b")).
+		Step(run("b").After("a")).
+		Run(context.Background(), nil)
+	assert.Error(t, err)
+}